@@ -0,0 +1,200 @@
+// Command templexp expands ${VAR} templates in files or standard input with
+// the grammar used by cfgm config values, like envsubst:
+//
+//	templexp config.tmpl > config.yaml
+//	templexp --strict --allow-unset OPTIONAL < deploy.tmpl
+//	templexp --vars config.tmpl
+//
+// Variables come from the environment, and the file, env, and base64
+// resolver namespaces are available as in cfgm. With --vars, templexp lists
+// the variables the input references instead of expanding it.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/lwmacct/251207-go-pkg-cfgm/pkg/templexp"
+)
+
+func main() {
+	if err := newCommand(os.LookupEnv).Run(context.Background(), os.Args); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		if excerpt := errorExcerpt(err); excerpt != "" {
+			_, _ = fmt.Fprintln(os.Stderr, excerpt)
+		}
+		os.Exit(1)
+	}
+}
+
+func newCommand(lookup templexp.LookupFunc) *cli.Command {
+	return &cli.Command{
+		Name:      "templexp",
+		Usage:     "expand ${VAR} templates in files or standard input",
+		ArgsUsage: "[file ...]",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "strict", Usage: "fail on references to unset variables without a default"},
+			&cli.StringSliceFlag{Name: "allow-unset", Usage: "variables that may be unset with --strict"},
+			&cli.BoolFlag{Name: "vars", Usage: "list referenced variables instead of expanding"},
+		},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			files := cmd.Args().Slice()
+			if len(files) == 0 {
+				files = []string{"-"}
+			}
+			opts := []templexp.Option{
+				templexp.WithResolver("file", templexp.FileResolver()),
+				templexp.WithResolver("env", templexp.EnvResolver(lookup)),
+				templexp.WithResolver("base64", templexp.Base64Resolver()),
+			}
+			if cmd.Bool("strict") {
+				opts = append(opts, templexp.WithStrict(cmd.StringSlice("allow-unset")...))
+			}
+			if cmd.Bool("vars") {
+				return listVars(cmd, files)
+			}
+			for _, name := range files {
+				err := readInput(cmd, name, func(r io.Reader) error {
+					return templexp.ExpandReader(r, cmd.Writer, lookup, opts...)
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// readInput calls read with the named file, or standard input for "-", and
+// prefixes errors with the file name and the position they report.
+func readInput(cmd *cli.Command, name string, read func(io.Reader) error) error {
+	var in io.Reader = cmd.Reader
+	if name != "-" {
+		file, err := os.Open(name) //nolint:gosec // the input file is named on the command line
+		if err != nil {
+			return err
+		}
+		defer func() { _ = file.Close() }()
+		in = file
+	} else {
+		name = "<stdin>"
+	}
+	if err := read(in); err != nil {
+		if line, column, message, ok := errorPosition(err); ok {
+			return &inputError{location: fmt.Sprintf("%s:%d:%d", name, line, column), message: message, err: err}
+		}
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// inputError prints a templexp error after the file:line:col it occurred
+// at, without the position the error itself reports.
+type inputError struct {
+	location string
+	message  string
+	err      error
+}
+
+func (e *inputError) Error() string { return e.location + ": " + e.message }
+
+func (e *inputError) Unwrap() error { return e.err }
+
+// varUsage orders how a variable is used, from least to most demanding.
+type varUsage int
+
+const (
+	usageDefault varUsage = iota
+	usageOptional
+	usageRequired
+)
+
+func (u varUsage) String() string {
+	return [...]string{"default", "optional", "required"}[u]
+}
+
+// listVars prints each referenced variable once, in order of first use, with
+// the most demanding use: required when a reference fails if it is unset,
+// default when every reference provides a default, and optional otherwise.
+func listVars(cmd *cli.Command, files []string) error {
+	strict := cmd.Bool("strict")
+	allowed := cmd.StringSlice("allow-unset")
+	var names []string
+	usages := make(map[string]varUsage)
+	for _, name := range files {
+		err := readInput(cmd, name, func(r io.Reader) error {
+			content, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			tmpl, err := templexp.Parse(string(content))
+			if err != nil {
+				return err
+			}
+			for _, v := range tmpl.Vars() {
+				key := v.Name
+				if v.Namespace != "" {
+					key = v.Namespace + ":" + v.Name
+				}
+				usage := usageOptional
+				switch {
+				case v.Required:
+					usage = usageRequired
+				case v.HasDefault:
+					usage = usageDefault
+				case v.Op == templexp.OpAlternateIfSet || v.Op == templexp.OpAlternateIfNonEmpty:
+				case strict && !slices.Contains(allowed, key):
+					usage = usageRequired
+				}
+				previous, seen := usages[key]
+				if !seen {
+					names = append(names, key)
+				}
+				usages[key] = max(previous, usage)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, name := range names {
+		if _, err := fmt.Fprintf(cmd.Writer, "%s\t%s\n", name, usages[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// errorPosition returns the line and column reported by err and its message
+// without them.
+func errorPosition(err error) (int, int, string, bool) {
+	var syntaxErr *templexp.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return syntaxErr.Line, syntaxErr.Column, "templexp: syntax error: " + syntaxErr.Message, true
+	}
+	var requiredErr *templexp.RequiredError
+	if errors.As(err, &requiredErr) {
+		return requiredErr.Line, requiredErr.Column, requiredErr.Error(), true
+	}
+	return 0, 0, "", false
+}
+
+func errorExcerpt(err error) string {
+	var syntaxErr *templexp.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return syntaxErr.Excerpt
+	}
+	var requiredErr *templexp.RequiredError
+	if errors.As(err, &requiredErr) {
+		return requiredErr.Excerpt
+	}
+	return ""
+}
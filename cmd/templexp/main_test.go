@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runTemplexp(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+	variables := map[string]string{"HOST": "example.com"}
+	cmd := newCommand(func(name string) (string, bool) {
+		value, found := variables[name]
+		return value, found
+	})
+	var out bytes.Buffer
+	cmd.Reader, cmd.Writer = strings.NewReader(stdin), &out
+	err := cmd.Run(t.Context(), append([]string{"templexp"}, args...))
+	return out.String(), err
+}
+
+func TestExpandsFilesAndStdin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte("host: ${HOST}\nport: ${PORT:-8080}\n"), 0o600))
+
+	out, err := runTemplexp(t, "cost: $$5 ${HOST^^}\n", path, "-")
+	require.NoError(t, err)
+	assert.Equal(t, "host: example.com\nport: 8080\ncost: $5 EXAMPLE.COM\n", out)
+}
+
+func TestStrictReportsPosition(t *testing.T) {
+	_, err := runTemplexp(t, "host: ${HOST}\nuser: ${USER_NAME}\n", "--strict")
+	require.EqualError(t, err, "<stdin>:2:7: templexp: USER_NAME: variable is unset")
+
+	_, err = runTemplexp(t, "host: ${HOST}\nport: ${PORT:=80}\n")
+	require.EqualError(t, err, "<stdin>:2:13: templexp: syntax error: assignment operators := and = are not supported")
+	assert.Equal(t, "port: ${PORT:=80}\n            ^", errorExcerpt(err))
+
+	out, err := runTemplexp(t, "user: ${USER_NAME}\n", "--strict", "--allow-unset", "USER_NAME")
+	require.NoError(t, err)
+	assert.Equal(t, "user: \n", out)
+}
+
+func TestListsVars(t *testing.T) {
+	input := "${HOST:-a} ${USER_NAME} ${PW:?} ${HOST} ${TLS:+on} ${env:HOME:-/}\n"
+	out, err := runTemplexp(t, input, "--vars")
+	require.NoError(t, err)
+	assert.Equal(t, "HOST\toptional\nUSER_NAME\toptional\nPW\trequired\nTLS\toptional\nenv:HOME\tdefault\n", out)
+
+	out, err = runTemplexp(t, input, "--vars", "--strict")
+	require.NoError(t, err)
+	assert.Contains(t, out, "HOST\trequired\nUSER_NAME\trequired\n")
+	assert.Contains(t, out, "TLS\toptional\n")
+}
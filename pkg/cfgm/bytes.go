@@ -0,0 +1,100 @@
+package cfgm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+type bytesSource struct {
+	name   string
+	format string
+	data   []byte
+}
+
+// Bytes loads a config document held in memory, such as a whole YAML
+// document passed in one environment variable. format names a registered
+// Format; when empty, the extension of name selects it, and otherwise a
+// document starting with "{" is read as JSON and anything else as YAML.
+func Bytes(name, format string, data []byte) Source {
+	return &bytesSource{name: name, format: strings.TrimSpace(format), data: bytes.Clone(data)}
+}
+
+func (s *bytesSource) Name() string {
+	return "bytes:" + s.name
+}
+
+func (s *bytesSource) Load(_ context.Context, schema Schema) (map[string]any, error) {
+	return decodeNamedBytes(schema, s.name, s.format, s.data)
+}
+
+type readerSource struct {
+	name   string
+	format string
+	reader io.Reader
+
+	once sync.Once
+	data []byte
+	err  error
+}
+
+// Reader loads a config document from r, such as a pipe on stdin. The reader
+// is consumed on the first Load and the content is reused by later loads.
+// format is resolved as in Bytes.
+func Reader(name, format string, r io.Reader) Source {
+	if r == nil {
+		panic("cfgm: reader must not be nil")
+	}
+	return &readerSource{name: name, format: strings.TrimSpace(format), reader: r}
+}
+
+func (s *readerSource) Name() string {
+	return "reader:" + s.name
+}
+
+func (s *readerSource) Load(_ context.Context, schema Schema) (map[string]any, error) {
+	s.once.Do(func() {
+		s.data, s.err = io.ReadAll(s.reader)
+	})
+	if s.err != nil {
+		return nil, fmt.Errorf("read %s: %w", s.name, s.err)
+	}
+
+	return decodeNamedBytes(schema, s.name, s.format, s.data)
+}
+
+func decodeNamedBytes(schema Schema, name, formatName string, data []byte) (map[string]any, error) {
+	format, err := detectFormat(schema, name, formatName, data)
+	if err != nil {
+		return nil, err
+	}
+	configMap, err := decodeConfigBytes(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", name, err)
+	}
+
+	return configMap, nil
+}
+
+// detectFormat resolves an explicit format name, then the extension of name,
+// then sniffs JSON objects from YAML documents.
+func detectFormat(schema Schema, name, formatName string, data []byte) (Format, error) {
+	if formatName != "" {
+		return schema.formats.resolve(formatName, "")
+	}
+	extension := filepath.Ext(name)
+	for _, format := range schema.formats.all() {
+		if hasExtension(format, extension) {
+			return schema.formats.byPath(name)
+		}
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		return schema.formats.resolve("json", "")
+	}
+
+	return schema.formats.resolve("yaml", "")
+}
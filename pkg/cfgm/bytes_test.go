@@ -0,0 +1,85 @@
+package cfgm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+)
+
+func TestBytesDetectsFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		source Source
+	}{
+		{name: "yaml sniffed", source: Bytes("APP_CONFIG_YAML", "", []byte("server:\n  addr: :9000\n"))},
+		{name: "json sniffed", source: Bytes("APP_CONFIG", "", []byte(` {"server": {"addr": ":9000"}}`))},
+		{name: "extension", source: Bytes("generated.toml", "", []byte("[server]\naddr = \":9000\"\n"))},
+		{name: "explicit", source: Bytes("inline", "jsonc", []byte("{server: {addr: ':9000',},}"))},
+		{name: "reader", source: Reader("pipe", "", strings.NewReader("server:\n  addr: :9000\n"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, report, err := New(bindingDefaults(), WithoutDefaultPaths()).LoadReport(t.Context(), tt.source)
+			require.NoError(t, err)
+			assert.Equal(t, ":9000", cfg.Server.Addr)
+			assert.Equal(t, []SourceReport{{Name: tt.source.Name(), Keys: []string{"server.addr"}}}, report.Sources)
+		})
+	}
+}
+
+func TestBytesErrors(t *testing.T) {
+	manager := New(bindingDefaults(), WithoutDefaultPaths())
+
+	_, err := manager.Load(t.Context(), Bytes("APP_CONFIG", "", []byte("server:\n  typo: 1\n")))
+	require.ErrorContains(t, err, "bytes:APP_CONFIG")
+	require.ErrorContains(t, err, "server.typo")
+
+	_, err = manager.Load(t.Context(), Bytes("APP_CONFIG", "", []byte("{bad")))
+	require.ErrorContains(t, err, "parse APP_CONFIG")
+
+	_, err = manager.Load(t.Context(), Bytes("APP_CONFIG", "ini", nil))
+	require.ErrorContains(t, err, `unknown config format "ini"`)
+
+	_, err = manager.Load(t.Context(), Reader("pipe", "", iotest.ErrReader(errors.New("broken pipe"))))
+	require.ErrorContains(t, err, "read pipe: broken pipe")
+
+	assert.Panics(t, func() { Reader("nil", "", nil) })
+}
+
+func TestReaderIsConsumedOnce(t *testing.T) {
+	source := Reader("pipe", "yaml", strings.NewReader("server:\n  addr: :9000\n"))
+	manager := New(bindingDefaults(), WithoutDefaultPaths())
+	for range 2 {
+		cfg, err := manager.Load(t.Context(), source)
+		require.NoError(t, err)
+		assert.Equal(t, ":9000", cfg.Server.Addr)
+	}
+}
+
+func TestManagerActionReadsConfigFromStdin(t *testing.T) {
+	manager := New(bindingDefaults(), WithoutDefaultPaths())
+	var loaded *bindingTestConfig
+	var report *Report
+	root := &cli.Command{
+		Name:   "app",
+		Reader: strings.NewReader(`{"server": {"addr": ":9100"}}`),
+		Commands: []*cli.Command{{
+			Name: "server",
+			Action: manager.ActionReport(func(_ context.Context, _ *cli.Command, cfg *bindingTestConfig, r *Report) error {
+				loaded, report = cfg, r
+				return nil
+			}),
+		}},
+	}
+	manager.MustConfigure(root)
+
+	require.NoError(t, root.Run(t.Context(), []string{"app", "-c", "-", "server"}))
+	assert.Equal(t, ":9100", loaded.Server.Addr)
+	assert.Equal(t, "reader:stdin", report.Sources[0].Name)
+}
@@ -2,6 +2,8 @@ package cfgm
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
 
 	"github.com/urfave/cli/v3"
@@ -9,11 +11,22 @@ import (
 
 const configFlagName = "config"
 const envPrefixFlagName = "env-prefix"
+const secretsDirFlagName = "secrets-dir"
+const setFlagName = "set"
 
-func rootFlags() []cli.Flag {
+// stdinConfigPath makes --config read the config document from stdin.
+const stdinConfigPath = "-"
+
+func rootFlags(secretsDirEnv string) []cli.Flag {
+	secretsDir := &cli.StringFlag{Name: secretsDirFlagName, Usage: "密钥文件目录"}
+	if secretsDirEnv != "" {
+		secretsDir.Sources = cli.EnvVars(secretsDirEnv)
+	}
 	return []cli.Flag{
-		&cli.StringFlag{Name: configFlagName, Aliases: []string{"c"}, Usage: "配置文件路径"},
+		&cli.StringFlag{Name: configFlagName, Aliases: []string{"c"}, Usage: "配置文件路径, - 表示从标准输入读取"},
 		&cli.StringFlag{Name: envPrefixFlagName, Aliases: []string{"e"}, Usage: "环境变量前缀"},
+		secretsDir,
+		newAssignmentsFlag(),
 	}
 }
 
@@ -64,6 +77,14 @@ func commandConfigPath(cmd *cli.Command) string {
 	return ""
 }
 
+// commandStdin returns the root command's Reader, which defaults to os.Stdin.
+func commandStdin(cmd *cli.Command) io.Reader {
+	if reader := cmd.Root().Reader; reader != nil {
+		return reader
+	}
+	return os.Stdin
+}
+
 func commandEnvPrefix(cmd *cli.Command) (string, bool) {
 	if cmd == nil {
 		return "", false
@@ -76,6 +97,34 @@ func commandEnvPrefix(cmd *cli.Command) (string, bool) {
 	return "", false
 }
 
+func commandSecretsDir(cmd *cli.Command) string {
+	if cmd == nil {
+		return ""
+	}
+	for _, command := range cmd.Lineage() {
+		if command.IsSet(secretsDirFlagName) {
+			return command.String(secretsDirFlagName)
+		}
+	}
+	return ""
+}
+
+func commandAssignments(cmd *cli.Command) []string {
+	if cmd == nil {
+		return nil
+	}
+	// --set is persistent, so every command in the lineage sees the flag of
+	// the root; read it once from the command that defines it.
+	for _, command := range cmd.Lineage() {
+		for _, flag := range command.Flags {
+			if flag, ok := flag.(*assignmentsFlag); ok {
+				return append([]string(nil), flag.value.assignments...)
+			}
+		}
+	}
+	return nil
+}
+
 func commandRootName(cmd *cli.Command) string {
 	if cmd == nil {
 		return ""
@@ -1,25 +1,19 @@
 package cfgm
 
-import (
-	"os"
-	"path/filepath"
-)
-
+// DefaultPaths lists optional config file locations for appName, as built by
+// NewSearchPaths. Each location is probed with the extensions of every
+// registered Format.
 func DefaultPaths(appName ...string) []string {
-	var paths []string
-	if len(appName) > 0 && appName[0] != "" {
-		name := appName[0]
-		paths = appendConfigFormats(paths, "."+name)
-		if home, err := os.UserHomeDir(); err == nil {
-			paths = appendConfigFormats(paths, filepath.Join(home, "."+name))
-		}
-		paths = appendConfigFormats(paths, "/etc/"+name+"/config")
+	name := ""
+	if len(appName) > 0 {
+		name = appName[0]
 	}
-	paths = appendConfigFormats(paths, "config")
-	paths = appendConfigFormats(paths, filepath.Join("config", "config"))
-	return paths
+	return NewSearchPaths(name).Paths()
 }
 
-func appendConfigFormats(paths []string, base string) []string {
-	return append(paths, base+".yaml", base+".yml", base+".json")
+func appendConfigFormats(paths []string, base string, extensions []string) []string {
+	for _, extension := range extensions {
+		paths = append(paths, base+extension)
+	}
+	return paths
 }
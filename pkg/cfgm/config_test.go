@@ -3,10 +3,14 @@ package cfgm
 import (
 	"bytes"
 	"context"
+	"errors"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/lwmacct/251207-go-pkg-cfgm/pkg/templexp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -89,6 +93,9 @@ func TestManagerSearchesDefaultPaths(t *testing.T) {
 		{name: "yaml", file: "config.yaml", content: "name: from-yaml\n"},
 		{name: "yml", file: "config.yml", content: "name: from-yml\n"},
 		{name: "json", file: "config.json", content: `{"name":"from-json"}`},
+		{name: "toml", file: "config.toml", content: "name = \"from-toml\"\n"},
+		{name: "jsonc", file: "config.jsonc", content: "{\n  // comment\n  name: 'from-jsonc',\n}\n"},
+		{name: "json5", file: "config.json5", content: "{name: 'from-json5'}"},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -208,6 +215,75 @@ func TestFileOptionalAndRequired(t *testing.T) {
 	require.ErrorContains(t, err, "none of the config files exist")
 }
 
+func TestFilesExpandPathsAndGlobs(t *testing.T) {
+	dir := writeConfigDir(t, map[string]string{
+		"app/20-workers.yaml": "server:\n  workers: 4\n",
+		"app/10-addr.yaml":    "server:\n  addr: :9000\n  workers: 1\n",
+		"app/notes.txt":       "ignored",
+		"home/.app.yaml":      "server:\n  debug: true\n",
+	})
+	t.Setenv("HOME", filepath.Join(dir, "home"))
+	t.Setenv("APP_CONFIG_DIR", filepath.Join(dir, "app"))
+	manager := New(bindingDefaults(), WithoutDefaultPaths())
+
+	cfg, report, err := manager.LoadReport(t.Context(), File("${APP_CONFIG_DIR}/*.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, ":9000", cfg.Server.Addr)
+	assert.Equal(t, 1, cfg.Server.Workers, "first match in lexical order")
+	assert.Equal(t, []SourceReport{{
+		Name:  "file:${APP_CONFIG_DIR}/*.yaml",
+		Keys:  []string{"server.addr", "server.workers"},
+		Files: []string{filepath.Join(dir, "app", "10-addr.yaml")},
+	}}, report.Sources)
+
+	cfg, report, err = manager.LoadReport(t.Context(), Files([]string{"${APP_CONFIG_DIR}/*.yaml", "~/.app.yaml"}, MergeMatches()))
+	require.NoError(t, err)
+	assert.Equal(t, ":9000", cfg.Server.Addr)
+	assert.Equal(t, 4, cfg.Server.Workers)
+	assert.True(t, cfg.Server.Debug)
+	assert.Equal(t, []string{
+		"file:" + filepath.Join(dir, "app", "10-addr.yaml"),
+		"file:" + filepath.Join(dir, "app", "20-workers.yaml"),
+		"file:" + filepath.Join(dir, "home", ".app.yaml"),
+	}, []string{report.Sources[0].Name, report.Sources[1].Name, report.Sources[2].Name})
+
+	_, err = manager.Load(t.Context(), File("${APP_CONFIG_DIR}/*.json"))
+	require.ErrorContains(t, err, "none of the config files exist")
+
+	_, err = manager.Load(t.Context(), File("${APP_CONFIG_DIR}/*.json", MergeMatches(), Optional()))
+	require.NoError(t, err)
+
+	_, err = manager.Load(t.Context(), File("${APP_CONFIG_DIR/config.yaml"))
+	require.ErrorContains(t, err, "expand ${APP_CONFIG_DIR/config.yaml")
+
+	_, err = manager.Load(t.Context(), File("[.yaml"))
+	require.ErrorContains(t, err, "match [.yaml")
+}
+
+func TestFilesLoadLiteralPathsWithPatternCharacters(t *testing.T) {
+	dir := writeConfigDir(t, map[string]string{
+		"app[1].yaml":  "server:\n  addr: :9001\n",
+		"[draft.yaml":  "server:\n  addr: :9002\n",
+		"a${b}.yaml":   "server:\n  addr: :9003\n",
+		"a${c:-x}.yml": "server:\n  addr: :9004\n",
+	})
+	manager := New(bindingDefaults(), WithoutDefaultPaths())
+
+	for name, addr := range map[string]string{
+		"app[1].yaml":  ":9001",
+		"[draft.yaml":  ":9002",
+		"a${b}.yaml":   ":9003",
+		"a${c:-x}.yml": ":9004",
+	} {
+		cfg, err := manager.Load(t.Context(), File(filepath.Join(dir, name)))
+		require.NoError(t, err, name)
+		assert.Equal(t, addr, cfg.Server.Addr, name)
+	}
+
+	_, err := manager.Load(t.Context(), File(filepath.Join(dir, "app[2].yaml")))
+	require.ErrorContains(t, err, "none of the config files exist")
+}
+
 func TestManagerTemplateExpansion(t *testing.T) {
 	type Config struct {
 		Name     string `json:"name"`
@@ -318,6 +394,271 @@ func TestManagerTemplateExpansionReportsValuePath(t *testing.T) {
 	assert.ErrorContains(t, err, "Redis password is required")
 }
 
+func TestManagerTemplateResolvers(t *testing.T) {
+	type Config struct {
+		Password string `json:"password"`
+		Home     string `json:"home"`
+		Token    string `json:"token"`
+		Vault    string `json:"vault"`
+	}
+	dir := writeConfigDir(t, map[string]string{
+		"db-password": "s3cret\n",
+		"app.yaml":    "home: from-file\n",
+	})
+	t.Setenv("CFG_HOME", "/home/app")
+	t.Setenv("CFG_DIR", dir)
+	path := writeTempConfig(t, "password: ${file:"+filepath.Join(dir, "db-password")+"}\n"+
+		"home: ${env:CFG_HOME}\ntoken: ${base64:dG9rZW4=}\nvault: ${vault:db/creds:-none}\n")
+	vault := func(argument string) (string, bool, error) {
+		return "vault-" + argument, argument == "db/creds", nil
+	}
+
+	cfg, err := New(Config{}, WithoutDefaultPaths(), WithTemplateResolver("vault", vault)).Load(t.Context(), File(path))
+	require.NoError(t, err)
+	assert.Equal(t, Config{Password: "s3cret", Home: "/home/app", Token: "token", Vault: "vault-db/creds"}, *cfg)
+
+	cfg, err = New(Config{}, WithoutDefaultPaths(), WithTemplateResolver("vault", vault)).
+		Load(t.Context(), File("${env:CFG_DIR}/app.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", cfg.Home)
+
+	upper := func(argument string) (string, bool, error) { return strings.ToUpper(argument), true, nil }
+	cfg, err = New(Config{Home: "${env:home}"}, WithoutDefaultPaths(), WithTemplateResolver("env", upper)).Load(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, "HOME", cfg.Home)
+
+	_, err = New(Config{}, WithoutDefaultPaths()).Load(t.Context(), File(path))
+	var syntaxErr *templexp.SyntaxError
+	require.ErrorAs(t, err, &syntaxErr)
+	assert.Equal(t, 7, syntaxErr.Offset)
+	require.ErrorContains(t, err, "root.vault")
+	require.ErrorContains(t, err, `unknown resolver namespace "vault"`)
+
+	failing := func(string) (string, bool, error) { return "", false, errors.New("sealed") }
+	_, err = New(Config{}, WithoutDefaultPaths(), WithTemplateResolver("vault", failing)).Load(t.Context(), File(path))
+	require.ErrorContains(t, err, "root.vault")
+	require.ErrorContains(t, err, "sealed")
+
+	assert.Panics(t, func() { WithTemplateResolver("bad name", vault) })
+	assert.Panics(t, func() { WithTemplateResolver("vault", nil) })
+}
+
+func TestManagerTemplateReferences(t *testing.T) {
+	type Upstream struct {
+		URL  string   `json:"url"`
+		Port int      `json:"port"`
+		Tags []string `json:"tags"`
+	}
+	type Config struct {
+		Server struct {
+			Host string   `json:"host"`
+			Port int      `json:"port"`
+			Tags []string `json:"tags"`
+		} `json:"server"`
+		Public   string            `json:"public"`
+		Upstream Upstream          `json:"upstream"`
+		Labels   map[string]string `json:"labels"`
+		Backup   Upstream          `json:"backup"`
+	}
+	t.Setenv("CFG_HOST", "api.internal")
+	path := writeTempConfig(t, `
+public: https://${cfg:server.host}:${cfg:server.port}
+upstream:
+  url: ${cfg:public}/v1
+  port: ${cfg:server.port}
+  tags: ${cfg:server.tags}
+labels:
+  host: ${cfg:server.host}
+  missing: ${cfg:server.nope:-none}
+backup: ${cfg:upstream}
+server:
+  host: ${CFG_HOST}
+  port: 8443
+  tags: [a, b]
+`)
+
+	cfg, err := New(Config{}, WithoutDefaultPaths()).Load(t.Context(), File(path))
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.internal:8443", cfg.Public)
+	assert.Equal(t, Upstream{URL: "https://api.internal:8443/v1", Port: 8443, Tags: []string{"a", "b"}}, cfg.Upstream)
+	assert.Equal(t, cfg.Upstream, cfg.Backup)
+	assert.Equal(t, map[string]string{"host": "api.internal", "missing": "none"}, cfg.Labels)
+
+	cfg, err = New(Config{}, WithoutDefaultPaths()).Load(t.Context(), File(path), Set("server.tags=[\"c\"]"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, cfg.Upstream.Tags, "references see the merged config")
+
+	_, err = New(Config{}, WithoutDefaultPaths(), WithoutTemplateExpansion()).Load(t.Context(), File(path))
+	require.ErrorContains(t, err, "must be", "references are plain strings without expansion")
+}
+
+func TestManagerTemplateReferenceErrors(t *testing.T) {
+	type Config struct {
+		A      string            `json:"a"`
+		B      string            `json:"b"`
+		C      string            `json:"c"`
+		Nested map[string]string `json:"nested"`
+	}
+	tests := []struct {
+		name    string
+		content string
+		message string
+	}{
+		{name: "cycle", content: "a: ${cfg:b}\nb: x-${cfg:c}\nc: ${cfg:a}\n", message: "reference cycle: a -> b -> c -> a"},
+		{name: "self", content: "a: ${cfg:a}\n", message: "reference cycle: a -> a"},
+		{name: "parent", content: "nested:\n  x: ${cfg:nested}\n", message: "reference cycle: nested -> nested.x -> nested"},
+		{name: "object in string", content: "a: x-${cfg:nested}\nnested: {x: y}\n", message: "nested is not a scalar value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(Config{}, WithoutDefaultPaths()).Load(t.Context(), File(writeTempConfig(t, tt.content)))
+			require.ErrorContains(t, err, "expand template at root.")
+			require.ErrorContains(t, err, tt.message)
+		})
+	}
+}
+
+func TestManagerValidatesReferencedValues(t *testing.T) {
+	type Backup struct {
+		Host string `json:"host"`
+	}
+	type Config struct {
+		Labels map[string]string `json:"labels"`
+		Backup Backup            `json:"backup"`
+		Port   int               `json:"port"`
+		On     bool              `json:"on"`
+		Name   string            `json:"name"`
+	}
+	tests := []struct {
+		name    string
+		content string
+		message string
+	}{
+		{name: "unknown key", content: "labels: {host: a, extra: b}\nbackup: ${cfg:labels}\n", message: "unknown config keys:\n  - backup.extra"},
+		{name: "number to bool", content: "port: 5\non: ${cfg:port}\n", message: `config key "on" must be a bool, got 5`},
+		{name: "bool to number", content: "on: true\nport: ${cfg:on}\n", message: `config key "port" must be a number, got true`},
+		{name: "missing whole", content: "name: ${cfg:nope}\n", message: "templexp: cfg:nope: variable is unset"},
+		{name: "missing embedded", content: "name: x-${cfg:nope}\n", message: "templexp: cfg:nope: variable is unset"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(Config{}, WithoutDefaultPaths()).Load(t.Context(), File(writeTempConfig(t, tt.content)))
+			require.ErrorContains(t, err, "expand template at root.")
+			require.ErrorContains(t, err, tt.message)
+		})
+	}
+
+	cfg, err := New(Config{}, WithoutDefaultPaths()).Load(t.Context(), File(writeTempConfig(t,
+		"labels: {host: a}\nbackup: ${cfg:labels}\nport: 5\nname: ${cfg:port}-${cfg:nope:-none}\n")))
+	require.NoError(t, err)
+	assert.Equal(t, Backup{Host: "a"}, cfg.Backup)
+	assert.Equal(t, "5-none", cfg.Name)
+}
+
+func TestManagerStrictTemplates(t *testing.T) {
+	type Config struct {
+		Server struct {
+			Addr string `json:"addr"`
+			Name string `json:"name"`
+		} `json:"server"`
+	}
+	t.Setenv("CFG_HOST", "example.com")
+	path := writeTempConfig(t, "server:\n  addr: ${CFG_HOST}:${CFG_PORT}\n  name: ${CFG_NAME}\n")
+
+	cfg, err := New(Config{}, WithoutDefaultPaths()).Load(t.Context(), File(path))
+	require.NoError(t, err)
+	assert.Equal(t, "example.com:", cfg.Server.Addr)
+
+	_, err = New(Config{}, WithoutDefaultPaths(), WithStrictTemplates("CFG_NAME")).Load(t.Context(), File(path))
+	var requiredErr *templexp.RequiredError
+	require.ErrorAs(t, err, &requiredErr)
+	assert.Equal(t, "CFG_PORT", requiredErr.Name)
+	assert.ErrorContains(t, err, "expand template at root.server.addr: templexp: CFG_PORT: variable is unset")
+
+	t.Setenv("CFG_PORT", "8080")
+	cfg, err = New(Config{}, WithoutDefaultPaths(), WithStrictTemplates("CFG_NAME")).Load(t.Context(), File(path))
+	require.NoError(t, err)
+	assert.Equal(t, "example.com:8080", cfg.Server.Addr)
+	assert.Empty(t, cfg.Server.Name)
+
+	_, err = New(Config{}, WithoutDefaultPaths(), WithStrictTemplates()).Load(t.Context(), File("${CFG_DIR}/config.yaml"))
+	require.ErrorAs(t, err, &requiredErr)
+	assert.Equal(t, "CFG_DIR", requiredErr.Name)
+}
+
+func TestManagerTemplateErrorPositions(t *testing.T) {
+	type Config struct {
+		Server struct {
+			Addr string `json:"addr"`
+		} `json:"server"`
+		Hosts  []string `json:"hosts"`
+		Script string   `json:"script"`
+	}
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		location string
+		offset   int
+	}{
+		{name: "plain", file: "config.yaml", content: "server:\n  addr: ${HOST}:${PORT:?}\n", location: ":2:17", offset: 8},
+		{name: "quoted", file: "config.yaml", content: "server:\n  addr: \"x ${PORT:?}\"\n", location: ":2:12", offset: 2},
+		{name: "sequence", file: "config.yaml", content: "hosts:\n  - a\n  - b${PORT:?}\n", location: ":3:6", offset: 1},
+		{name: "literal block", file: "config.yaml", content: "script: |\n  echo\n  run ${PORT:?}\n", location: ":3:7", offset: 9},
+		{name: "json", file: "config.json", content: "{\"server\": {\"addr\": \"${PORT:?}\"}}", location: ":1:22", offset: 0},
+		// TOML and JSONC values are located by file name only.
+		{name: "toml", file: "config.toml", content: "[server]\naddr = \"${PORT:?}\"\n", location: "", offset: 0},
+		{name: "jsonc", file: "config.jsonc", content: "{\n  // addr\n  server: {addr: \"${PORT:?}\"},\n}", location: "", offset: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+
+			_, err := New(Config{}, WithoutDefaultPaths()).Load(t.Context(), File(path))
+			var requiredErr *templexp.RequiredError
+			require.ErrorAs(t, err, &requiredErr)
+			assert.Equal(t, tt.offset, requiredErr.Offset)
+			assert.ErrorContains(t, err, "expand template in effective config: "+path+tt.location+": expand template at root.")
+		})
+	}
+}
+
+func TestManagerTemplateErrorPositionsThroughReferences(t *testing.T) {
+	type Config struct {
+		A string `json:"a"`
+		B string `json:"b"`
+	}
+	for name, a := range map[string]string{"whole": "${cfg:b}", "embedded": "x${cfg:b}"} {
+		t.Run(name, func(t *testing.T) {
+			path := writeTempConfig(t, "a: \""+a+"\"\nb: \"0123456789012345678901234567890123456 ${UNCLOSED\"\n")
+			_, err := New(Config{}, WithoutDefaultPaths()).Load(t.Context(), File(path))
+			var syntaxErr *templexp.SyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			assert.Equal(t, 38, syntaxErr.Offset)
+			require.ErrorContains(t, err, "expand template at root.a: ")
+			require.ErrorContains(t, err, path+":2:43: expand template at root.b: ")
+			assert.Equal(t, 1, strings.Count(err.Error(), path), "only the failing value is located")
+		})
+	}
+}
+
+func TestManagerTemplateErrorPositionsFollowOrigins(t *testing.T) {
+	type Config struct {
+		Name string `json:"name"`
+		Addr string `json:"addr"`
+	}
+	path := writeTempConfig(t, "name: app\nprofiles:\n  dev:\n    addr: ${PORT:?}\n")
+	t.Setenv("APP_PROFILE", "dev")
+	_, err := New(Config{}, WithoutDefaultPaths(), WithProfiles("APP_PROFILE")).Load(t.Context(), File(path))
+	require.ErrorContains(t, err, path+":4:11: expand template at root.addr")
+
+	// A value overridden by the environment no longer points into the file.
+	t.Setenv("APP_ADDR", "${PORT:?}")
+	_, err = New(Config{}, WithoutDefaultPaths()).Load(t.Context(), File(writeTempConfig(t, "addr: ${PORT:?}\n")), Env("APP_"))
+	require.ErrorContains(t, err, "expand template in effective config: expand template at root.addr")
+}
+
 func TestManagerRejectsNilContext(t *testing.T) {
 	type Config struct {
 		Name string `json:"name"`
@@ -343,10 +684,12 @@ func TestExampleYAMLKeepsMapCommentOnMap(t *testing.T) {
 
 func TestDefaultPaths(t *testing.T) {
 	assert.Equal(t, []string{
-		"config.yaml", "config.yml", "config.json",
-		"config/config.yaml", "config/config.yml", "config/config.json",
+		"config.yaml", "config.yml", "config.json", "config.toml", "config.jsonc", "config.json5",
+		"config/config.yaml", "config/config.yml", "config/config.json", "config/config.toml",
+		"config/config.jsonc", "config/config.json5",
 	}, DefaultPaths())
-	assert.Len(t, DefaultPaths("app"), 15)
+	t.Setenv("XDG_CONFIG_DIRS", "")
+	assert.Len(t, DefaultPaths("app"), 42)
 }
 
 func TestManagerHonorsCanceledContext(t *testing.T) {
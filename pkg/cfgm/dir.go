@@ -0,0 +1,90 @@
+package cfgm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type dirSource struct {
+	path    string
+	pattern string
+	file    fileSource
+}
+
+// Dir loads every file in path whose name matches pattern, in lexical order,
+// as in a conf.d directory. Later fragments override earlier ones, and each
+// fragment is reported as its own "file:<path>" entry in Report.Sources.
+//
+// An empty pattern matches files with any registered format extension.
+// Hidden files and subdirectories are skipped. Optional allows the directory
+// to be absent and FileFormat parses every fragment with one format.
+func Dir(path, pattern string, opts ...FileOption) Source {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		panic(fmt.Errorf("cfgm: invalid dir pattern %q: %w", pattern, err))
+	}
+	source := &dirSource{path: path, pattern: pattern}
+	for _, opt := range opts {
+		opt(&source.file)
+	}
+
+	return source
+}
+
+func (s *dirSource) Name() string {
+	return "dir:" + s.path
+}
+
+func (s *dirSource) Load(ctx context.Context, schema Schema) (map[string]any, error) {
+	return mergeLayers(s.loadLayers(ctx, schema))
+}
+
+func (s *dirSource) loadLayers(ctx context.Context, schema Schema) ([]sourceLayer, error) {
+	schema = schema.withFS(s.file.fsys)
+	entries, err := schema.readDir(s.path)
+	if err != nil {
+		if os.IsNotExist(err) && s.file.optional {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read dir %s: %w", s.path, err)
+	}
+
+	var layers []sourceLayer
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || !s.matches(schema, entry.Name()) {
+			continue
+		}
+
+		path := filepath.Join(s.path, entry.Name())
+		data, err := readConfigFile(schema, path, s.file.format)
+		if err != nil {
+			return nil, err
+		}
+		fragment, err := loadIncludes(ctx, schema, "file:"+path, path, data, nil)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, fragment...)
+	}
+
+	return layers, nil
+}
+
+func (s *dirSource) matches(schema Schema, name string) bool {
+	if s.pattern != "" {
+		matched, _ := filepath.Match(s.pattern, name)
+		return matched
+	}
+	for _, extension := range schema.formats.extensions() {
+		if strings.EqualFold(filepath.Ext(name), extension) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,101 @@
+package cfgm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	return dir
+}
+
+type dirTestConfig struct {
+	Name   string `json:"name"`
+	Server struct {
+		Addr    string `json:"addr"`
+		Timeout string `json:"timeout"`
+	} `json:"server"`
+	Tags []string `json:"tags"`
+}
+
+func TestDirMergesFragmentsInLexicalOrder(t *testing.T) {
+	dir := writeConfigDir(t, map[string]string{
+		"20-server.yaml":  "server:\n  addr: :9090\n",
+		"10-base.yaml":    "name: base\nserver:\n  addr: :8080\n  timeout: 5s\ntags: [a, b]\n",
+		"30-tags.json":    `{"tags": ["c"]}`,
+		"README.md":       "ignored",
+		".99-hidden.yaml": "name: hidden\n",
+		"sub/40.yaml":     "name: nested\n",
+	})
+
+	cfg, report, err := New(dirTestConfig{}, WithoutDefaultPaths()).LoadReport(t.Context(), Dir(dir, ""))
+	require.NoError(t, err)
+	assert.Equal(t, "base", cfg.Name)
+	assert.Equal(t, ":9090", cfg.Server.Addr)
+	assert.Equal(t, "5s", cfg.Server.Timeout)
+	assert.Equal(t, []string{"c"}, cfg.Tags)
+	assert.Equal(t, []SourceReport{
+		{Name: "file:" + filepath.Join(dir, "10-base.yaml"), Keys: []string{"name", "server.addr", "server.timeout", "tags"}},
+		{Name: "file:" + filepath.Join(dir, "20-server.yaml"), Keys: []string{"server.addr"}},
+		{Name: "file:" + filepath.Join(dir, "30-tags.json"), Keys: []string{"tags"}},
+	}, report.Sources)
+}
+
+func TestDirPattern(t *testing.T) {
+	dir := writeConfigDir(t, map[string]string{
+		"a.yaml": "name: a\n",
+		"b.conf": "name: b\n",
+	})
+
+	cfg, err := New(dirTestConfig{}, WithoutDefaultPaths()).Load(t.Context(), Dir(dir, "*.conf"))
+	require.NoError(t, err)
+	assert.Equal(t, "b", cfg.Name)
+
+	assert.Panics(t, func() { Dir(dir, "[") })
+}
+
+func TestDirValidationErrorNamesFragment(t *testing.T) {
+	dir := writeConfigDir(t, map[string]string{
+		"10-ok.yaml":  "name: ok\n",
+		"20-bad.yaml": "typo: true\n",
+	})
+
+	_, err := New(dirTestConfig{}, WithoutDefaultPaths()).Load(t.Context(), Dir(dir, ""))
+	require.ErrorContains(t, err, "file:"+filepath.Join(dir, "20-bad.yaml"))
+	require.ErrorContains(t, err, "typo")
+}
+
+func TestDirMissingAndEmpty(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "conf.d")
+	manager := New(dirTestConfig{Name: "default"}, WithoutDefaultPaths())
+
+	_, err := manager.Load(t.Context(), Dir(missing, ""))
+	require.ErrorContains(t, err, "read dir "+missing)
+
+	cfg, report, err := manager.LoadReport(t.Context(), Dir(missing, "", Optional()))
+	require.NoError(t, err)
+	assert.Equal(t, "default", cfg.Name)
+	assert.Equal(t, []SourceReport{{Name: "dir:" + missing}}, report.Sources)
+}
+
+func TestDirLoadMergesWithoutManager(t *testing.T) {
+	dir := writeConfigDir(t, map[string]string{
+		"a.yaml": "name: a\nserver:\n  addr: :1\n",
+		"b.yaml": "name: b\n",
+	})
+
+	data, err := Dir(dir, "").Load(t.Context(), Schema{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"name": "b", "server": map[string]any{"addr": ":1"}}, data)
+}
@@ -8,13 +8,16 @@
 //
 // Later sources replace earlier values. Manager.Load searches optional
 // DefaultPaths before caller-provided sources unless WithoutDefaultPaths is
-// set. Unknown keys are rejected by default.
+// set. Files are parsed by the Format registered for their extension; JSON,
+// TOML, and YAML are built in, and RegisterFormat or WithFormat add more.
+// Unknown keys are rejected by default.
 //
 // # CLI Integration
 //
 // Manager.Configure walks a completed urfave command tree. It adds root
-// --config/-c and --env-prefix/-e flags and projects each actionable command's
-// matching config subtree into typed local flags:
+// --config/-c, --env-prefix/-e, --secrets-dir, and repeatable --set path=value
+// flags, plus --profile with WithProfiles, and projects each actionable command's matching config subtree into
+// typed local flags:
 //
 //	manager := cfgm.New(DefaultConfig(),
 //	    cfgm.CLIAlias("server.addr", "a"),
@@ -32,7 +35,9 @@
 // Command paths map directly to json-tagged config structs. The example maps
 // Config.Server to the server command, so server.addr becomes --addr.
 // Manager.Action applies defaults, default paths, an explicit config file, the
-// selected environment prefix, and explicitly set CLI flags in that order.
+// selected environment prefix, the secrets directory (--secrets-dir, or the
+// variable named by WithSecretsDirEnv), explicitly set CLI flags, and --set
+// assignments in that order.
 // Anonymous non-pointer structs tagged with cfgm:",inline" contribute their
 // fields at the containing config path across every source and generated
 // output. Inline types cannot use codecs, and duplicate paths are rejected.
@@ -0,0 +1,278 @@
+package cfgm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type dotEnvSource struct {
+	path         string
+	prefix       string
+	optional     bool
+	templateVars bool
+}
+
+// DotEnv loads schema fields from a .env file, mapping variable names the
+// same way as Env. An empty prefix maps unprefixed names such as SERVER_ADDR.
+func DotEnv(path, prefix string, opts ...DotEnvOption) Source {
+	source := &dotEnvSource{path: path, prefix: prefix}
+	for _, opt := range opts {
+		opt(source)
+	}
+
+	return source
+}
+
+// DotEnvOption configures DotEnv sources.
+type DotEnvOption func(*dotEnvSource)
+
+// DotEnvOptional allows the .env file to be absent.
+func DotEnvOptional() DotEnvOption {
+	return func(s *dotEnvSource) {
+		s.optional = true
+	}
+}
+
+// DotEnvTemplateVars exposes every variable in the file to ${VAR} template
+// expansion, whether or not it maps to a schema field. Variables from the
+// process environment take precedence, and later DotEnv sources override
+// earlier ones.
+func DotEnvTemplateVars() DotEnvOption {
+	return func(s *dotEnvSource) {
+		s.templateVars = true
+	}
+}
+
+func (s *dotEnvSource) Name() string {
+	return "dotenv:" + s.path
+}
+
+func (s *dotEnvSource) Load(ctx context.Context, schema Schema) (map[string]any, error) {
+	content, err := os.ReadFile(s.path) //nolint:gosec // path is provided by the caller
+	if err != nil {
+		if os.IsNotExist(err) && s.optional {
+			return map[string]any{}, nil
+		}
+		return nil, fmt.Errorf("read %s: %w", s.path, err)
+	}
+
+	variables, err := parseDotEnv(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", s.path, err)
+	}
+	if s.templateVars {
+		schema.addTemplateVariables(variables)
+	}
+
+	return loadEnvFields(ctx, schema, s.prefix, func(name string) (string, bool) {
+		value, ok := variables[name]
+		return value, ok
+	}, false)
+}
+
+// parseDotEnv parses KEY=VALUE lines in the common .env dialect:
+//
+//   - blank lines and lines starting with # are ignored
+//   - an optional "export " prefix is accepted
+//   - unquoted values are trimmed and end at " #" comments
+//   - single-quoted values are literal and may span lines
+//   - double-quoted values may span lines and support \n, \r, \t, \", \\ and \$
+//
+// Later assignments override earlier ones. Values are not interpolated.
+func parseDotEnv(content string) (map[string]string, error) {
+	parser := dotEnvParser{text: strings.ReplaceAll(content, "\r\n", "\n"), line: 1}
+	variables := map[string]string{}
+	for {
+		parser.skipBlank()
+		if parser.done() {
+			return variables, nil
+		}
+		name, value, err := parser.assignment()
+		if err != nil {
+			return nil, err
+		}
+		variables[name] = value
+	}
+}
+
+type dotEnvParser struct {
+	text string
+	pos  int
+	line int
+}
+
+func (p *dotEnvParser) done() bool {
+	return p.pos >= len(p.text)
+}
+
+func (p *dotEnvParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+// skipBlank skips whitespace, newlines and comment lines.
+func (p *dotEnvParser) skipBlank() {
+	for !p.done() {
+		switch p.text[p.pos] {
+		case ' ', '\t':
+			p.pos++
+		case '\n':
+			p.pos++
+			p.line++
+		case '#':
+			p.skipLine()
+		default:
+			return
+		}
+	}
+}
+
+func (p *dotEnvParser) skipSpaces() {
+	for !p.done() && (p.text[p.pos] == ' ' || p.text[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// skipLine advances to the next newline without consuming it.
+func (p *dotEnvParser) skipLine() {
+	for !p.done() && p.text[p.pos] != '\n' {
+		p.pos++
+	}
+}
+
+func (p *dotEnvParser) assignment() (string, string, error) {
+	if rest := p.text[p.pos:]; strings.HasPrefix(rest, "export") && len(rest) > 6 && (rest[6] == ' ' || rest[6] == '\t') {
+		p.pos += len("export")
+		p.skipSpaces()
+	}
+
+	start := p.pos
+	for !p.done() && isDotEnvNameChar(p.text[p.pos]) {
+		p.pos++
+	}
+	name := p.text[start:p.pos]
+	if name == "" || isDigit(name[0]) {
+		return "", "", p.errorf("invalid variable name")
+	}
+	p.skipSpaces()
+	if p.done() || p.text[p.pos] != '=' {
+		return "", "", p.errorf("expected '=' after %s", name)
+	}
+	p.pos++
+	p.skipSpaces()
+
+	var (
+		value string
+		err   error
+	)
+	switch {
+	case p.done():
+	case p.text[p.pos] == '"':
+		value, err = p.doubleQuoted()
+	case p.text[p.pos] == '\'':
+		value, err = p.singleQuoted()
+	default:
+		value = p.unquoted()
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	return name, value, nil
+}
+
+func (p *dotEnvParser) unquoted() string {
+	start := p.pos
+	for !p.done() && p.text[p.pos] != '\n' {
+		if p.text[p.pos] == '#' && p.pos > start && (p.text[p.pos-1] == ' ' || p.text[p.pos-1] == '\t') {
+			value := p.text[start:p.pos]
+			p.skipLine()
+			return strings.TrimSpace(value)
+		}
+		p.pos++
+	}
+
+	return strings.TrimSpace(p.text[start:p.pos])
+}
+
+func (p *dotEnvParser) singleQuoted() (string, error) {
+	startLine := p.line
+	p.pos++
+	start := p.pos
+	for !p.done() && p.text[p.pos] != '\'' {
+		if p.text[p.pos] == '\n' {
+			p.line++
+		}
+		p.pos++
+	}
+	if p.done() {
+		return "", fmt.Errorf("line %d: unterminated single-quoted value", startLine)
+	}
+	value := p.text[start:p.pos]
+	p.pos++
+
+	return value, p.endOfValue()
+}
+
+func (p *dotEnvParser) doubleQuoted() (string, error) {
+	startLine := p.line
+	p.pos++
+	var value strings.Builder
+	for !p.done() {
+		char := p.text[p.pos]
+		switch char {
+		case '"':
+			p.pos++
+			return value.String(), p.endOfValue()
+		case '\\':
+			if p.pos+1 >= len(p.text) {
+				p.pos++
+				continue
+			}
+			p.pos += 2
+			switch escaped := p.text[p.pos-1]; escaped {
+			case 'n':
+				value.WriteByte('\n')
+			case 'r':
+				value.WriteByte('\r')
+			case 't':
+				value.WriteByte('\t')
+			case '"', '\\', '$':
+				value.WriteByte(escaped)
+			case '\n':
+				// A backslash before a newline continues the line.
+				p.line++
+			default:
+				value.WriteByte('\\')
+				value.WriteByte(escaped)
+			}
+			continue
+		case '\n':
+			p.line++
+		}
+		value.WriteByte(char)
+		p.pos++
+	}
+
+	return "", fmt.Errorf("line %d: unterminated double-quoted value", startLine)
+}
+
+// endOfValue accepts trailing spaces and a comment after a quoted value.
+func (p *dotEnvParser) endOfValue() error {
+	p.skipSpaces()
+	if p.done() || p.text[p.pos] == '\n' {
+		return nil
+	}
+	if p.text[p.pos] == '#' {
+		p.skipLine()
+		return nil
+	}
+
+	return p.errorf("unexpected %q after quoted value", p.text[p.pos])
+}
+
+func isDotEnvNameChar(char byte) bool {
+	return char == '_' || char == '.' || char == '-' ||
+		('a' <= char && char <= 'z') || ('A' <= char && char <= 'Z') || isDigit(char)
+}
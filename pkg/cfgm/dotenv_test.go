@@ -0,0 +1,146 @@
+package cfgm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeDotEnv(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestParseDotEnv(t *testing.T) {
+	variables, err := parseDotEnv(`# leading comment
+PLAIN=value
+export EXPORTED=yes
+SPACED = padded value   # trailing comment
+HASH=a#b
+EMPTY=
+SINGLE='literal \n ${X}' # comment
+DOUBLE="tab\there \"quoted\" \$HOME \\ \q"
+MULTI="line one
+line two"
+MULTI_SINGLE='a
+b'
+CONTINUED="one \
+two"
+WINDOWS=crlf` + "\r\n" + `PLAIN=override
+`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"PLAIN":        "override",
+		"EXPORTED":     "yes",
+		"SPACED":       "padded value",
+		"HASH":         "a#b",
+		"EMPTY":        "",
+		"SINGLE":       `literal \n ${X}`,
+		"DOUBLE":       "tab\there \"quoted\" $HOME \\ \\q",
+		"MULTI":        "line one\nline two",
+		"MULTI_SINGLE": "a\nb",
+		"CONTINUED":    "one two",
+		"WINDOWS":      "crlf",
+	}, variables)
+}
+
+func TestParseDotEnvErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		message string
+	}{
+		{name: "missing equals", content: "A=1\nNAME value\n", message: "line 2: expected '=' after NAME"},
+		{name: "invalid name", content: "1NAME=value\n", message: "line 1: invalid variable name"},
+		{name: "unterminated double", content: "A=1\nB=\"open\nstill open\n", message: "line 2: unterminated double-quoted value"},
+		{name: "unterminated single", content: "B='open\n", message: "line 1: unterminated single-quoted value"},
+		{name: "text after quote", content: "A='x' y\n", message: "line 1: unexpected 'y' after quoted value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseDotEnv(tt.content)
+			require.Error(t, err)
+			assert.EqualError(t, err, tt.message)
+		})
+	}
+}
+
+func TestManagerLoadsDotEnv(t *testing.T) {
+	type Config struct {
+		Name   string `json:"name"`
+		Server struct {
+			Addr string `json:"addr"`
+		} `json:"server"`
+		Tags []string `json:"tags"`
+	}
+	path := writeDotEnv(t, "APP_NAME=\"from dotenv\"\nexport APP_SERVER_ADDR=:8080\nAPP_TAGS='[\"a\",\"b\"]'\nOTHER=ignored\n")
+	t.Setenv("APP_NAME", "from-env")
+
+	manager := New(Config{}, WithoutDefaultPaths())
+	cfg, report, err := manager.LoadReport(t.Context(), DotEnv(path, "APP_"))
+	require.NoError(t, err)
+	assert.Equal(t, "from dotenv", cfg.Name)
+	assert.Equal(t, ":8080", cfg.Server.Addr)
+	assert.Equal(t, []string{"a", "b"}, cfg.Tags)
+	require.Len(t, report.Sources, 1)
+	assert.Equal(t, "dotenv:"+path, report.Sources[0].Name)
+	assert.Equal(t, []string{"name", "server.addr", "tags"}, report.Sources[0].Keys)
+
+	cfg, err = manager.Load(t.Context(), DotEnv(path, "APP_"), Env("APP_"))
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", cfg.Name)
+}
+
+func TestDotEnvMissingFile(t *testing.T) {
+	type Config struct {
+		Name string `json:"name"`
+	}
+	path := filepath.Join(t.TempDir(), ".env")
+	manager := New(Config{Name: "default"}, WithoutDefaultPaths())
+
+	_, err := manager.Load(t.Context(), DotEnv(path, "APP_"))
+	require.ErrorContains(t, err, "read "+path)
+
+	cfg, err := manager.Load(t.Context(), DotEnv(path, "APP_", DotEnvOptional()))
+	require.NoError(t, err)
+	assert.Equal(t, "default", cfg.Name)
+}
+
+func TestDotEnvRejectsInvalidValues(t *testing.T) {
+	type Config struct {
+		Tags []string `json:"tags"`
+	}
+	path := writeDotEnv(t, "APP_TAGS=api,edge\n")
+	_, err := New(Config{}, WithoutDefaultPaths()).Load(t.Context(), DotEnv(path, "APP_"))
+	require.ErrorContains(t, err, "dotenv:"+path)
+	require.ErrorContains(t, err, "APP_TAGS")
+}
+
+func TestDotEnvTemplateVars(t *testing.T) {
+	type Config struct {
+		DSN  string `json:"dsn"`
+		Host string `json:"host"`
+	}
+	dotenv := writeDotEnv(t, "DB_USER=admin\nDB_HOST=dotenv-host\n")
+	config := writeTempConfig(t, "dsn: ${DB_USER}@${DB_HOST}\nhost: ${DB_HOST}\n")
+	t.Setenv("DB_HOST", "env-host")
+	manager := New(Config{}, WithoutDefaultPaths())
+
+	cfg, err := manager.Load(t.Context(), DotEnv(dotenv, "APP_", DotEnvTemplateVars()), File(config))
+	require.NoError(t, err)
+	assert.Equal(t, "admin@env-host", cfg.DSN)
+	assert.Equal(t, "env-host", cfg.Host)
+
+	cfg, err = manager.Load(t.Context(), DotEnv(dotenv, "APP_"), File(config))
+	require.NoError(t, err)
+	assert.Equal(t, "@env-host", cfg.DSN)
+
+	cfg, err = manager.Load(t.Context(), File(config))
+	require.NoError(t, err)
+	assert.Equal(t, "@env-host", cfg.DSN, "template variables must not leak across loads")
+}
@@ -0,0 +1,284 @@
+package cfgm
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+const (
+	encryptedPrefix = "ENC["
+	encryptedSuffix = "]"
+)
+
+// KeyProvider supplies the AES key used to decrypt ENC[...] values. The key
+// is requested once per load, and only when an encrypted value is present.
+type KeyProvider interface {
+	Key(ctx context.Context) ([]byte, error)
+}
+
+// KeyProviderFunc adapts a function to KeyProvider.
+type KeyProviderFunc func(ctx context.Context) ([]byte, error)
+
+func (f KeyProviderFunc) Key(ctx context.Context) ([]byte, error) {
+	return f(ctx)
+}
+
+// KeyFile reads a base64-encoded key from path, as written by GenerateKey.
+func KeyFile(path string) KeyProvider {
+	return KeyProviderFunc(func(context.Context) ([]byte, error) {
+		content, err := readSecretFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return decodeKey(content, path)
+	})
+}
+
+// KeyEnv reads a base64-encoded key from the environment variable name.
+func KeyEnv(name string) KeyProvider {
+	return KeyProviderFunc(func(context.Context) ([]byte, error) {
+		value, ok := os.LookupEnv(name)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("environment variable %s is not set", name)
+		}
+		return decodeKey(value, name)
+	})
+}
+
+// GenerateKey returns a new random base64-encoded AES-256 key for KeyFile
+// and KeyEnv.
+func GenerateKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+func decodeKey(encoded, source string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("decode key from %s: %w", source, err)
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("key from %s must be 16, 24, or 32 bytes, got %d", source, len(key))
+	}
+}
+
+// WithDecryption decrypts ENC[...] values in fields tagged cfgm:",secret"
+// with keys from provider. Encrypted values in other fields are rejected.
+// Decryption runs after template expansion, so decrypted values are never
+// expanded.
+func WithDecryption(provider KeyProvider) Option {
+	if provider == nil {
+		panic("cfgm: key provider must not be nil")
+	}
+	return managerOptionFunc(func(options *managerOptions) {
+		options.keyProvider = provider
+	})
+}
+
+// IsEncrypted reports whether value has the ENC[...] form.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix) && strings.HasSuffix(value, encryptedSuffix)
+}
+
+// EncryptValue encrypts plaintext with AES-GCM and returns it as ENC[...].
+func EncryptValue(key []byte, plaintext string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed) + encryptedSuffix, nil
+}
+
+// DecryptValue decrypts an ENC[...] value produced by EncryptValue.
+func DecryptValue(key []byte, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", errors.New("value is not ENC[...]")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(value[len(encryptedPrefix) : len(value)-len(encryptedSuffix)])
+	if err != nil {
+		return "", fmt.Errorf("decode encrypted value: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("encrypted value is truncated")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("encrypted value cannot be decrypted with this key")
+	}
+	return string(plaintext), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptDocument encrypts the plaintext string values of secret fields in a
+// config document and returns it re-encoded in the same format. name selects
+// the format as in Bytes. Values that are already encrypted are kept.
+// Comments and key order are not preserved.
+func (m *Manager[T]) EncryptDocument(ctx context.Context, name string, data []byte) ([]byte, error) {
+	if m.keyProvider == nil {
+		return nil, errors.New("cfgm: encrypting requires WithDecryption")
+	}
+	schema := Schema{model: m.schema, codecs: m.codecs, formats: m.formats}
+	format, err := detectFormat(schema, name, "", data)
+	if err != nil {
+		return nil, err
+	}
+	document, err := decodeConfigBytes(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", name, err)
+	}
+	if err := m.schema.validateData(document, m.codecs, !m.strictUnknownKeys, m.expandTemplates); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	key, err := m.keyProvider.Key(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load encryption key: %w", err)
+	}
+	if _, err := walkConfigStrings(document, m.schema.rootType, "", false,
+		func(_ string, secret bool, text string) (string, error) {
+			if !secret || IsEncrypted(text) {
+				return text, nil
+			}
+			return EncryptValue(key, text)
+		},
+	); err != nil {
+		return nil, err
+	}
+	return format.Encode(document)
+}
+
+// EncryptFile encrypts the secret values of the config file at path in
+// place. See EncryptDocument.
+func (m *Manager[T]) EncryptFile(ctx context.Context, path string) error {
+	content, err := os.ReadFile(path) //nolint:gosec // path is provided by the caller
+	if err != nil {
+		return err
+	}
+	encrypted, err := m.EncryptDocument(ctx, path, content)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, encrypted, info.Mode().Perm())
+}
+
+// checkEncryptedValues rejects ENC[...] values outside secret fields.
+func checkEncryptedValues(data map[string]any, typ reflect.Type) error {
+	_, err := walkConfigStrings(data, typ, "", false, func(path string, secret bool, text string) (string, error) {
+		if !secret && IsEncrypted(text) {
+			return "", fmt.Errorf("config key %q: encrypted values are only allowed in secret fields", path)
+		}
+		return text, nil
+	})
+	return err
+}
+
+// decryptConfigValues decrypts ENC[...] values in secret fields of the
+// effective config.
+func decryptConfigValues(ctx context.Context, data map[string]any, typ reflect.Type, provider KeyProvider) error {
+	var key []byte
+	_, err := walkConfigStrings(data, typ, "", false, func(path string, secret bool, text string) (string, error) {
+		if !secret || !IsEncrypted(text) {
+			return text, nil
+		}
+		if provider == nil {
+			return "", fmt.Errorf("config key %q is encrypted but no key provider is configured", path)
+		}
+		if key == nil {
+			var err error
+			if key, err = provider.Key(ctx); err != nil {
+				return "", fmt.Errorf("load decryption key: %w", err)
+			}
+		}
+		plaintext, err := DecryptValue(key, text)
+		if err != nil {
+			return "", fmt.Errorf("decrypt %s: %w", path, err)
+		}
+		return plaintext, nil
+	})
+	return err
+}
+
+// walkConfigStrings replaces every string in value with the result of fn.
+// secret reports whether the value lies under a field tagged
+// cfgm:",secret"; typ is nil below keys the schema does not know.
+func walkConfigStrings(
+	value any, typ reflect.Type, path string, secret bool, fn func(path string, secret bool, text string) (string, error),
+) (any, error) {
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	switch typed := value.(type) {
+	case string:
+		return fn(path, secret, typed)
+	case map[string]any:
+		for _, key := range slices.Sorted(maps.Keys(typed)) {
+			child := typed[key]
+			childType, childSecret := reflect.Type(nil), secret
+			if typ != nil && typ.Kind() == reflect.Map {
+				childType = typ.Elem()
+			} else if typ != nil && typ.Kind() == reflect.Struct {
+				fields, _ := configFields(typ)
+				for _, configured := range fields {
+					if configTagName(configured.field) == key {
+						childType = configured.field.Type
+						childSecret = secret || cfgmTagOption(configured.field) == "secret"
+						break
+					}
+				}
+			}
+			replaced, err := walkConfigStrings(child, childType, joinSchemaPath(path, key), childSecret, fn)
+			if err != nil {
+				return nil, err
+			}
+			typed[key] = replaced
+		}
+	case []any:
+		var elemType reflect.Type
+		if typ != nil && (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) {
+			elemType = typ.Elem()
+		}
+		for index, item := range typed {
+			replaced, err := walkConfigStrings(item, elemType, fmt.Sprintf("%s[%d]", path, index), secret, fn)
+			if err != nil {
+				return nil, err
+			}
+			typed[index] = replaced
+		}
+	}
+	return value, nil
+}
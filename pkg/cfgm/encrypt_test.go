@@ -0,0 +1,199 @@
+package cfgm
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type encryptTestConfig struct {
+	Name     string `json:"name"`
+	Database struct {
+		User     string `json:"user"`
+		Password string `json:"password" cfgm:",secret"`
+	} `json:"database"`
+	Tokens map[string]string `json:"tokens" cfgm:",secret"`
+}
+
+func testKey(t *testing.T) (string, []byte) {
+	t.Helper()
+	encoded, err := GenerateKey()
+	require.NoError(t, err)
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	require.NoError(t, err)
+	return encoded, key
+}
+
+func TestEncryptValueRoundTrip(t *testing.T) {
+	_, key := testKey(t)
+	_, otherKey := testKey(t)
+
+	encrypted, err := EncryptValue(key, "s3cret")
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+	assert.NotContains(t, encrypted, "s3cret")
+
+	again, err := EncryptValue(key, "s3cret")
+	require.NoError(t, err)
+	assert.NotEqual(t, encrypted, again, "every encryption uses a fresh nonce")
+
+	plaintext, err := DecryptValue(key, encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", plaintext)
+
+	_, err = DecryptValue(otherKey, encrypted)
+	require.ErrorContains(t, err, "cannot be decrypted with this key")
+	_, err = DecryptValue(key, "ENC[AAAA]")
+	require.ErrorContains(t, err, "truncated")
+	_, err = DecryptValue(key, "ENC[not base64]")
+	require.ErrorContains(t, err, "decode encrypted value")
+	_, err = DecryptValue(key, "plain")
+	require.ErrorContains(t, err, "not ENC[...]")
+}
+
+func TestManagerDecodesSecretFieldsByJSONName(t *testing.T) {
+	type Config struct {
+		Password string `json:"db_password" cfgm:",secret"`
+	}
+	path := writeTempConfig(t, "db_password: plain\n")
+	cfg, err := New(Config{}, WithoutDefaultPaths()).Load(t.Context(), File(path))
+	require.NoError(t, err)
+	assert.Equal(t, "plain", cfg.Password)
+
+	encoded, key := testKey(t)
+	t.Setenv("APP_KEY", encoded)
+	password, err := EncryptValue(key, "db-pass")
+	require.NoError(t, err)
+	path = writeTempConfig(t, "db_password: "+password+"\n")
+	cfg, err = New(Config{}, WithoutDefaultPaths(), WithDecryption(KeyEnv("APP_KEY"))).Load(t.Context(), File(path))
+	require.NoError(t, err)
+	assert.Equal(t, "db-pass", cfg.Password)
+}
+
+func TestManagerDecryptsSecretFields(t *testing.T) {
+	encoded, key := testKey(t)
+	t.Setenv("APP_KEY", encoded)
+	password, err := EncryptValue(key, "db-pass")
+	require.NoError(t, err)
+	token, err := EncryptValue(key, "tok-$HOME")
+	require.NoError(t, err)
+	path := writeTempConfig(t, "database:\n  user: app\n  password: "+password+"\ntokens:\n  ci: "+token+"\n  plain: visible\n")
+
+	manager := New(encryptTestConfig{}, WithoutDefaultPaths(), WithDecryption(KeyEnv("APP_KEY")))
+	cfg, err := manager.Load(t.Context(), File(path))
+	require.NoError(t, err)
+	assert.Equal(t, "app", cfg.Database.User)
+	assert.Equal(t, "db-pass", cfg.Database.Password)
+	assert.Equal(t, map[string]string{"ci": "tok-$HOME", "plain": "visible"}, cfg.Tokens)
+
+	_, err = manager.Load(t.Context(), File(writeTempConfig(t, "database:\n  user: "+password+"\n")))
+	require.ErrorContains(t, err, `config key "database.user": encrypted values are only allowed in secret fields`)
+	require.ErrorContains(t, err, "file:")
+
+	_, err = New(encryptTestConfig{}, WithoutDefaultPaths()).Load(t.Context(), File(path))
+	require.ErrorContains(t, err, `config key "database.password" is encrypted but no key provider is configured`)
+
+	cfg, err = New(encryptTestConfig{}, WithoutDefaultPaths()).Load(t.Context(), Set("database.user="+password))
+	require.NoError(t, err)
+	assert.Equal(t, password, cfg.Database.User, "values stay verbatim in non-secret fields without WithDecryption")
+
+	_, otherKey := testKey(t)
+	wrongKey := KeyProviderFunc(func(context.Context) ([]byte, error) { return otherKey, nil })
+	_, err = New(encryptTestConfig{}, WithoutDefaultPaths(), WithDecryption(wrongKey)).Load(t.Context(), File(path))
+	require.ErrorContains(t, err, "decrypt database.password")
+
+	calls := 0
+	failing := KeyProviderFunc(func(context.Context) ([]byte, error) {
+		calls++
+		return nil, errors.New("vault sealed")
+	})
+	_, err = New(encryptTestConfig{Name: "plain"}, WithoutDefaultPaths(), WithDecryption(failing)).Load(t.Context())
+	require.NoError(t, err)
+	assert.Zero(t, calls, "the key is only requested for encrypted values")
+	_, err = New(encryptTestConfig{}, WithoutDefaultPaths(), WithDecryption(failing)).Load(t.Context(), File(path))
+	require.ErrorContains(t, err, "load decryption key: vault sealed")
+	assert.Equal(t, 1, calls)
+
+	assert.Panics(t, func() { WithDecryption(nil) })
+}
+
+func TestKeyProviders(t *testing.T) {
+	encoded, key := testKey(t)
+	path := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(path, []byte(encoded+"\n"), 0o600))
+
+	loaded, err := KeyFile(path).Key(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, key, loaded)
+
+	_, err = KeyFile(filepath.Join(t.TempDir(), "missing")).Key(t.Context())
+	require.Error(t, err)
+
+	t.Setenv("APP_KEY", base64.StdEncoding.EncodeToString([]byte("short")))
+	_, err = KeyEnv("APP_KEY").Key(t.Context())
+	require.ErrorContains(t, err, "must be 16, 24, or 32 bytes, got 5")
+
+	_, err = KeyEnv("APP_MISSING_KEY").Key(t.Context())
+	require.ErrorContains(t, err, "APP_MISSING_KEY is not set")
+}
+
+func TestManagerEncryptFile(t *testing.T) {
+	encoded, key := testKey(t)
+	keyPath := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(keyPath, []byte(encoded), 0o600))
+	manager := New(encryptTestConfig{}, WithoutDefaultPaths(), WithDecryption(KeyFile(keyPath)))
+
+	path := writeTempConfig(t, "database:\n  user: app\n  password: db-pass\ntokens:\n  ci: tok\n")
+	require.NoError(t, manager.EncryptFile(t.Context(), path))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "db-pass")
+	assert.Contains(t, string(content), "user: app")
+	assert.Equal(t, 2, strings.Count(string(content), encryptedPrefix))
+
+	require.NoError(t, manager.EncryptFile(t.Context(), path))
+	again, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(content), string(again), "encrypted values are kept")
+
+	cfg, err := manager.Load(t.Context(), File(path))
+	require.NoError(t, err)
+	assert.Equal(t, "db-pass", cfg.Database.Password)
+	assert.Equal(t, "tok", cfg.Tokens["ci"])
+
+	encrypted, err := manager.EncryptDocument(t.Context(), "inline.json", []byte(`{"database": {"password": "x"}}`))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(strings.TrimSpace(string(encrypted)), "{"))
+	plaintext, err := DecryptValue(key, extractEncrypted(t, string(encrypted)))
+	require.NoError(t, err)
+	assert.Equal(t, "x", plaintext)
+
+	_, err = manager.EncryptDocument(t.Context(), "inline.yaml", []byte("database:\n  typo: 1\n"))
+	require.ErrorContains(t, err, "database.typo")
+
+	_, err = New(encryptTestConfig{}).EncryptDocument(t.Context(), "inline.yaml", nil)
+	require.ErrorContains(t, err, "requires WithDecryption")
+}
+
+func extractEncrypted(t *testing.T, text string) string {
+	t.Helper()
+	start := strings.Index(text, encryptedPrefix)
+	require.GreaterOrEqual(t, start, 0)
+	end := strings.Index(text[start:], encryptedSuffix)
+	require.Positive(t, end)
+	return text[start : start+end+1]
+}
+
+func TestSecretTagValidation(t *testing.T) {
+	type Config struct {
+		Name string `json:"name" cfgm:",hidden"`
+	}
+	assert.PanicsWithError(t, `cfgm: config field Name has invalid cfgm tag ",hidden"`, func() { New(Config{}) })
+}
@@ -27,8 +27,12 @@ import (
 //	yaml := cfgm.ExampleYAML(DefaultConfig())
 //	os.WriteFile("config/config.example.yaml", yaml, 0644)
 func ExampleYAML[T any](cfg T) []byte {
-	node := structToNode(reflect.ValueOf(cfg), reflect.TypeOf(cfg))
-	node.HeadComment = "默认配置示例文件, 此文件由单元测试生成, 请勿直接修改\n复制此文件为 config.yaml 并根据需要修改"
+	return exampleYAML(reflect.ValueOf(cfg), reflect.TypeOf(cfg), "config.yaml")
+}
+
+func exampleYAML(val reflect.Value, typ reflect.Type, runtimeFile string) []byte {
+	node := structToNode(val, typ)
+	node.HeadComment = exampleHeadComment(runtimeFile)
 
 	var buf bytes.Buffer
 	enc := yamlv3.NewEncoder(&buf)
@@ -39,6 +43,54 @@ func ExampleYAML[T any](cfg T) []byte {
 	return normalizeBlankLines(buf.Bytes())
 }
 
+// ExampleTOML 将配置结构体序列化为带注释的 TOML 示例。
+//
+// key 和注释规则与 ExampleYAML 相同：单行 desc 写在行尾，多行 desc 和 struct
+// 的 desc 写在 key 或表头上方。TOML 没有 null，nil 指针字段不会输出。
+//
+// 使用示例：
+//
+//	toml := cfgm.ExampleTOML(DefaultConfig())
+//	os.WriteFile("config/config.example.toml", toml, 0644)
+func ExampleTOML[T any](cfg T) []byte {
+	return exampleTOML(reflect.ValueOf(cfg), reflect.TypeOf(cfg), "config.toml")
+}
+
+func exampleTOML(val reflect.Value, typ reflect.Type, runtimeFile string) []byte {
+	var buf bytes.Buffer
+	writeTOMLComment(&buf, exampleHeadComment(runtimeFile))
+	buf.WriteString("\n")
+	_ = writeTOMLTable(&buf, nil, structToOrderedFields(val, typ))
+
+	return buf.Bytes()
+}
+
+// ExampleJSONC 将配置结构体序列化为带 // 注释的 JSONC 示例。
+//
+// 注释规则与 ExampleYAML 相同：单行 desc 写在行尾，多行 desc 和复杂字段的 desc
+// 写在 key 上方。生成结果不含尾逗号，可同时作为 .jsonc 和 .json5 文件加载。
+//
+// 使用示例：
+//
+//	jsonc := cfgm.ExampleJSONC(DefaultConfig())
+//	os.WriteFile("config/config.example.jsonc", jsonc, 0644)
+func ExampleJSONC[T any](cfg T) []byte {
+	return exampleJSONC(reflect.ValueOf(cfg), reflect.TypeOf(cfg), "config.jsonc")
+}
+
+func exampleJSONC(val reflect.Value, typ reflect.Type, runtimeFile string) []byte {
+	var buf bytes.Buffer
+	writeJSONCComment(&buf, exampleHeadComment(runtimeFile), "")
+	_ = writeJSONCObject(&buf, structToOrderedFields(val, typ), "")
+	buf.WriteString("\n")
+
+	return buf.Bytes()
+}
+
+func exampleHeadComment(runtimeFile string) string {
+	return "默认配置示例文件, 此文件由单元测试生成, 请勿直接修改\n复制此文件为 " + runtimeFile + " 并根据需要修改"
+}
+
 // normalizeBlankLines removes indentation from whitespace-only lines while
 // preserving line count and surrounding content.
 func normalizeBlankLines(data []byte) []byte {
@@ -79,9 +131,23 @@ func MarshalJSON[T any](cfg T) []byte {
 	return buf.Bytes()
 }
 
+// MarshalTOML 将配置结构体序列化为 TOML（无注释）。
+//
+// 使用示例：
+//
+//	toml := cfgm.MarshalTOML(cfg)
+//	os.WriteFile("config/config.toml", toml, 0644)
+func MarshalTOML[T any](cfg T) []byte {
+	var buf bytes.Buffer
+	_ = writeTOMLTable(&buf, nil, structToOrderedFields(reflect.ValueOf(cfg), reflect.TypeOf(cfg)))
+
+	return buf.Bytes()
+}
+
 // InitConfigFile 将默认配置写入运行配置文件。
 //
-// 该函数用于显式初始化本地配置文件（如 config/config.yaml）。如果目标文件已存在，
+// 该函数用于显式初始化本地配置文件（如 config/config.yaml）。文件格式由扩展名在
+// 全局格式注册表中选择，未识别的扩展名写入 YAML。如果目标文件已存在，
 // 函数会返回错误并拒绝覆盖。
 func InitConfigFile[T any](defaultConfig T, configPath string) error {
 	if configPath == "" {
@@ -104,7 +170,15 @@ func InitConfigFile[T any](defaultConfig T, configPath string) error {
 		return fmt.Errorf("create config directory %s: %w", outputDir, err)
 	}
 
-	if err := os.WriteFile(outputPath, MarshalYAML(defaultConfig), 0600); err != nil {
+	format, err := (*formatSet)(nil).byPath(outputPath)
+	if err != nil {
+		return err
+	}
+	content, err := format.Encode(structToMap(defaultConfig))
+	if err != nil {
+		return fmt.Errorf("encode config file %s: %w", outputPath, err)
+	}
+	if err := os.WriteFile(outputPath, content, 0600); err != nil {
 		return fmt.Errorf("write config file %s: %w", outputPath, err)
 	}
 
@@ -324,6 +398,126 @@ type mapNodeEntry struct {
 	value reflect.Value
 }
 
+// orderedField 是保留顺序和注释的文档字段，供 TOML 与 JSONC 编码共用。
+// 对象值使用 []orderedField，以保留结构体字段顺序和 desc 注释。
+type orderedField struct {
+	key     string
+	comment string
+	value   any
+}
+
+// mapToOrderedFields 将通用 map 转换为按 key 排序的字段。
+func mapToOrderedFields(data map[string]any) []orderedField {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	fields := make([]orderedField, 0, len(keys))
+	for _, key := range keys {
+		fields = append(fields, orderedField{key: key, value: genericOrderedValue(data[key])})
+	}
+	return fields
+}
+
+func genericOrderedValue(value any) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		return mapToOrderedFields(typed)
+	case []any:
+		items := make([]any, len(typed))
+		for index, item := range typed {
+			items[index] = genericOrderedValue(item)
+		}
+		return items
+	case time.Duration:
+		return typed.String()
+	default:
+		return value
+	}
+}
+
+// structToOrderedFields 将结构体转换为保留字段顺序和 desc 注释的字段。
+func structToOrderedFields(val reflect.Value, typ reflect.Type) []orderedField {
+	if val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+		typ = typ.Elem()
+	}
+
+	fields, _ := configFields(typ)
+	out := make([]orderedField, 0, len(fields))
+	for _, configured := range fields {
+		field := configured.field
+		out = append(out, orderedField{
+			key:     configTagName(field),
+			comment: field.Tag.Get("desc"),
+			value:   orderedValue(val.FieldByIndex(configured.index), field.Type),
+		})
+	}
+
+	return out
+}
+
+// orderedValue 将值转换为 TOML 与 JSONC 编码共用的有序值：结构体和 map 转为
+// []orderedField，slice 转为 []any，标量保持原值。
+func orderedValue(val reflect.Value, typ reflect.Type) any {
+	if !val.IsValid() {
+		return nil
+	}
+	if val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return nil
+		}
+		inner := val.Elem()
+		return orderedValue(inner, inner.Type())
+	}
+	if typ.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return nil
+		}
+		return orderedValue(val.Elem(), typ.Elem())
+	}
+
+	switch typ {
+	case durationType:
+		return reflectAs[time.Duration](val).String()
+	case timeType:
+		return reflectAs[time.Time](val)
+	}
+	if isStructType(typ) {
+		return structToOrderedFields(val, typ)
+	}
+
+	switch val.Kind() { //nolint:exhaustive // scalar kinds are returned as-is for the TOML and JSONC writers
+	case reflect.Slice, reflect.Array:
+		items := make([]any, val.Len())
+		for index := range val.Len() {
+			elem := val.Index(index)
+			items[index] = orderedValue(elem, elem.Type())
+		}
+		return items
+	case reflect.Map:
+		entries := make([]mapNodeEntry, 0, val.Len())
+		iter := val.MapRange()
+		for iter.Next() {
+			entries = append(entries, mapNodeEntry{key: fmt.Sprintf("%v", iter.Key().Interface()), value: iter.Value()})
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].key < entries[j].key
+		})
+		fields := make([]orderedField, 0, len(entries))
+		for _, entry := range entries {
+			fields = append(fields, orderedField{key: entry.key, value: orderedValue(entry.value, entry.value.Type())})
+		}
+		return fields
+	default:
+		return val.Interface()
+	}
+}
+
 // ConfigFiles 声明一组由配置管理器驱动的配置文件。
 //
 // 使用示例：
@@ -355,20 +549,38 @@ func (f ConfigFiles[T]) WriteExample(t *testing.T) {
 		t.Fatalf("无法找到项目根目录: %v", err)
 	}
 
-	yamlBytes := ExampleYAML(f.Manager.defaults)
+	format, err := f.Manager.formats.byPath(outputPath)
+	if err != nil {
+		t.Fatalf("无法识别示例文件格式: %v", err)
+	}
+	content, err := exampleBytes(format, f.Manager.defaults, exampleRuntimeFile(f.RuntimeFile, format))
+	if err != nil {
+		t.Fatalf("生成示例配置失败: %v", err)
+	}
 
 	outputDir := filepath.Dir(outputPath)
 	if err := os.MkdirAll(outputDir, 0750); err != nil {
 		t.Fatalf("创建目录失败: %v", err)
 	}
 
-	if err := os.WriteFile(outputPath, yamlBytes, 0600); err != nil {
+	if err := os.WriteFile(outputPath, content, 0600); err != nil {
 		t.Fatalf("写入配置文件失败: %v", err)
 	}
 
 	t.Logf("✅ 已生成配置示例文件: %s", outputPath)
 }
 
+// exampleRuntimeFile 返回示例文件头注释中提示复制到的文件名。
+func exampleRuntimeFile(runtimeFile string, format Format) string {
+	if runtimeFile != "" {
+		return filepath.Base(runtimeFile)
+	}
+	if extensions := format.Extensions(); len(extensions) > 0 {
+		return "config" + extensions[0]
+	}
+	return "config"
+}
+
 // ValidateRuntimeConfig 使用同一配置定义校验 RuntimeFile。
 func (f ConfigFiles[T]) ValidateRuntimeConfig(t *testing.T) {
 	t.Helper()
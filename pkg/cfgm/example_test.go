@@ -15,11 +15,11 @@ func Example_defaultPaths() {
 	fmt.Println("基础路径数量:", len(paths))
 
 	paths = cfgm.DefaultPaths("app")
-	fmt.Println("带应用名路径数量:", len(paths))
+	fmt.Println("带应用名的首个路径:", paths[0])
 
 	// Output:
-	// 基础路径数量: 6
-	// 带应用名路径数量: 15
+	// 基础路径数量: 12
+	// 带应用名的首个路径: .app.yaml
 }
 
 func Example_exampleYAML() {
@@ -60,6 +60,45 @@ func Example_exampleYAML() {
 	//   port: 8080 # 服务器端口
 }
 
+func Example_exampleTOML() {
+	type ServerConfig struct {
+		Host string `json:"host" desc:"服务器主机地址"`
+		Port int    `json:"port" desc:"服务器端口"`
+	}
+	type AppConfig struct {
+		Name    string        `json:"name"    desc:"应用名称"`
+		Timeout time.Duration `json:"timeout" desc:"超时时间"`
+		Tags    []string      `json:"tags"    desc:"标签"`
+		Server  ServerConfig  `json:"server"  desc:"服务器配置"`
+	}
+
+	defaultCfg := AppConfig{
+		Name:    "example-app",
+		Timeout: 30 * time.Second,
+		Tags:    []string{"api", "edge"},
+		Server: ServerConfig{
+			Host: "localhost",
+			Port: 8080,
+		},
+	}
+
+	toml := cfgm.ExampleTOML(defaultCfg)
+	fmt.Print(string(toml))
+
+	// Output:
+	// # 默认配置示例文件, 此文件由单元测试生成, 请勿直接修改
+	// # 复制此文件为 config.toml 并根据需要修改
+	//
+	// name = "example-app" # 应用名称
+	// timeout = "30s" # 超时时间
+	// tags = ["api", "edge"] # 标签
+	//
+	// # 服务器配置
+	// [server]
+	// host = "localhost" # 服务器主机地址
+	// port = 8080 # 服务器端口
+}
+
 func ExampleManager_Load() {
 	type Config struct {
 		Name  string `json:"name"`
@@ -0,0 +1,277 @@
+package cfgm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	yamlv3 "go.yaml.in/yaml/v3"
+)
+
+// Format decodes and encodes one config file syntax.
+//
+// Decode returns the generic document shape shared by all sources: objects
+// as map[string]any, arrays as []any, and scalars as plain Go values. Encode
+// receives the same shape, with leaf values taken from the config struct.
+type Format interface {
+	// Name identifies the format in FileFormat overrides, such as "yaml".
+	Name() string
+	// Extensions lists file extensions including the leading dot, in the
+	// order DefaultPaths probes them.
+	Extensions() []string
+	Decode(data []byte) (map[string]any, error)
+	Encode(data map[string]any) ([]byte, error)
+}
+
+// exampleFormat is implemented by built-in formats that can write desc tags
+// as comments. Other formats fall back to Encode.
+type exampleFormat interface {
+	example(val reflect.Value, typ reflect.Type, runtimeFile string) []byte
+}
+
+var globalFormats = struct {
+	sync.RWMutex
+	formats []Format
+}{formats: []Format{
+	yamlFormat{},
+	jsonFormat{},
+	tomlFormat{},
+	jsoncFormat{name: "jsonc", extensions: []string{".jsonc"}},
+	jsoncFormat{name: "json5", extensions: []string{".json5"}},
+}}
+
+// RegisterFormat adds format to the process-wide registry consulted by every
+// Manager, DefaultPaths, and InitConfigFile. A format with the same name
+// replaces the registered one, including built-in formats.
+func RegisterFormat(format Format) {
+	validateFormat(format)
+	globalFormats.Lock()
+	defer globalFormats.Unlock()
+	globalFormats.formats = replaceFormat(globalFormats.formats, format)
+}
+
+// WithFormat registers format for one Manager. Manager formats take
+// precedence over globally registered formats with the same name or
+// extension.
+func WithFormat(format Format) Option {
+	validateFormat(format)
+	return managerOptionFunc(func(options *managerOptions) {
+		options.formats = replaceFormat(options.formats, format)
+	})
+}
+
+func validateFormat(format Format) {
+	if format == nil {
+		panic("cfgm: format must not be nil")
+	}
+	if strings.TrimSpace(format.Name()) == "" {
+		panic("cfgm: format name must not be empty")
+	}
+	for _, extension := range format.Extensions() {
+		if !strings.HasPrefix(extension, ".") || len(extension) < 2 {
+			panic(fmt.Errorf("cfgm: format %s has invalid extension %q", format.Name(), extension))
+		}
+	}
+}
+
+func replaceFormat(formats []Format, format Format) []Format {
+	out := slices.Clone(formats)
+	for index, existing := range out {
+		if strings.EqualFold(existing.Name(), format.Name()) {
+			out[index] = format
+			return out
+		}
+	}
+	return append(out, format)
+}
+
+// formatSet resolves formats for one Manager: its own formats layered over the
+// global registry, which is read on every lookup so formats registered after
+// New are still found.
+type formatSet struct {
+	local []Format
+}
+
+func (s *formatSet) all() []Format {
+	globalFormats.RLock()
+	formats := slices.Clone(globalFormats.formats)
+	globalFormats.RUnlock()
+	if s == nil {
+		return formats
+	}
+	for _, format := range s.local {
+		formats = replaceFormat(formats, format)
+	}
+	return formats
+}
+
+func (s *formatSet) byName(name string) (Format, bool) {
+	for _, format := range s.all() {
+		if strings.EqualFold(format.Name(), name) {
+			return format, true
+		}
+	}
+	return nil, false
+}
+
+// byPath selects a format by file extension. Local formats win over global
+// formats claiming the same extension. Unknown extensions fall back to YAML,
+// matching the behavior of files without a recognized extension.
+func (s *formatSet) byPath(path string) (Format, error) {
+	extension := filepath.Ext(path)
+	if s != nil {
+		for _, format := range s.local {
+			if hasExtension(format, extension) {
+				return format, nil
+			}
+		}
+	}
+	for _, format := range s.all() {
+		if hasExtension(format, extension) {
+			return format, nil
+		}
+	}
+	if format, ok := s.byName("yaml"); ok {
+		return format, nil
+	}
+	return nil, fmt.Errorf("no config format registered for %s", path)
+}
+
+// resolve returns the format named by override, or the format selected by
+// path when override is empty.
+func (s *formatSet) resolve(override, path string) (Format, error) {
+	if override == "" {
+		return s.byPath(path)
+	}
+	format, ok := s.byName(override)
+	if !ok {
+		return nil, fmt.Errorf("unknown config format %q", override)
+	}
+	return format, nil
+}
+
+func (s *formatSet) extensions() []string {
+	var extensions []string
+	for _, format := range s.all() {
+		for _, extension := range format.Extensions() {
+			if !slices.Contains(extensions, extension) {
+				extensions = append(extensions, extension)
+			}
+		}
+	}
+	return extensions
+}
+
+func hasExtension(format Format, extension string) bool {
+	for _, candidate := range format.Extensions() {
+		if strings.EqualFold(candidate, extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeConfigBytes decodes content with format and normalizes the document
+// into the generic shape validated by the Schema.
+func decodeConfigBytes(format Format, content []byte) (map[string]any, error) {
+	raw, err := format.Decode(content)
+	if err != nil {
+		return nil, err
+	}
+	normalized, ok := normalizeMapKeys(raw).(map[string]any)
+	if !ok || normalized == nil {
+		return map[string]any{}, nil
+	}
+	return normalized, nil
+}
+
+// exampleBytes renders defaults for runtimeFile, using commented examples
+// when the format supports them.
+func exampleBytes(format Format, defaults any, runtimeFile string) ([]byte, error) {
+	if example, ok := format.(exampleFormat); ok {
+		return example.example(reflect.ValueOf(defaults), reflect.TypeOf(defaults), runtimeFile), nil
+	}
+	return format.Encode(structToMap(defaults))
+}
+
+type yamlFormat struct{}
+
+func (yamlFormat) Name() string { return "yaml" }
+
+func (yamlFormat) Extensions() []string { return []string{".yaml", ".yml"} }
+
+func (yamlFormat) Decode(data []byte) (map[string]any, error) {
+	var raw any
+	if err := yamlv3.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return configRoot(raw)
+}
+
+func (yamlFormat) Encode(data map[string]any) ([]byte, error) {
+	return yamlv3.Marshal(data)
+}
+
+func (yamlFormat) example(val reflect.Value, typ reflect.Type, runtimeFile string) []byte {
+	return exampleYAML(val, typ, runtimeFile)
+}
+
+type jsonFormat struct{}
+
+func (jsonFormat) Name() string { return "json" }
+
+func (jsonFormat) Extensions() []string { return []string{".json"} }
+
+func (jsonFormat) Decode(data []byte) (map[string]any, error) {
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, jsonSyntaxHint(data, err)
+	}
+	return configRoot(raw)
+}
+
+func (jsonFormat) Encode(data map[string]any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type tomlFormat struct{}
+
+func (tomlFormat) Name() string { return "toml" }
+
+func (tomlFormat) Extensions() []string { return []string{".toml"} }
+
+func (tomlFormat) Decode(data []byte) (map[string]any, error) {
+	return decodeTOML(data)
+}
+
+func (tomlFormat) Encode(data map[string]any) ([]byte, error) {
+	return encodeTOML(data)
+}
+
+func (tomlFormat) example(val reflect.Value, typ reflect.Type, runtimeFile string) []byte {
+	return exampleTOML(val, typ, runtimeFile)
+}
+
+func configRoot(raw any) (map[string]any, error) {
+	normalized := normalizeMapKeys(raw)
+	if normalized == nil {
+		return map[string]any{}, nil
+	}
+	configMap, ok := normalized.(map[string]any)
+	if !ok {
+		return nil, errors.New("config root must be object")
+	}
+	return configMap, nil
+}
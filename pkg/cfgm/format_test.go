@@ -0,0 +1,178 @@
+package cfgm
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// propertiesFormat is a minimal key=value format used to exercise the
+// registry. Dotted keys become nested objects.
+type propertiesFormat struct{}
+
+func (propertiesFormat) Name() string { return "properties" }
+
+func (propertiesFormat) Extensions() []string { return []string{".properties"} }
+
+func (propertiesFormat) Decode(data []byte) (map[string]any, error) {
+	out := map[string]any{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid line %q", line)
+		}
+		setByPath(out, strings.TrimSpace(key), strings.TrimSpace(value))
+	}
+	return out, scanner.Err()
+}
+
+func (propertiesFormat) Encode(data map[string]any) ([]byte, error) {
+	var lines []string
+	for _, key := range flattenSchemaKeys(data) {
+		value := data
+		parts := strings.Split(key, ".")
+		for _, part := range parts[:len(parts)-1] {
+			value, _ = value[part].(map[string]any)
+		}
+		lines = append(lines, fmt.Sprintf("%s=%v", key, value[parts[len(parts)-1]]))
+	}
+	sort.Strings(lines)
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
+
+func restoreGlobalFormats(t *testing.T) {
+	t.Helper()
+	globalFormats.RLock()
+	saved := slices.Clone(globalFormats.formats)
+	globalFormats.RUnlock()
+	t.Cleanup(func() {
+		globalFormats.Lock()
+		globalFormats.formats = saved
+		globalFormats.Unlock()
+	})
+}
+
+type formatTestConfig struct {
+	Name   string `json:"name"`
+	Server struct {
+		Addr string `json:"addr"`
+	} `json:"server"`
+}
+
+func TestManagerFormatLoadsCustomExtension(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.properties")
+	require.NoError(t, os.WriteFile(path, []byte("name=from-properties\nserver.addr=:9090\n"), 0o600))
+
+	_, err := New(formatTestConfig{}, WithoutDefaultPaths()).Load(t.Context(), File(path))
+	require.Error(t, err, "properties files are parsed as YAML without a registered format")
+
+	cfg, err := New(formatTestConfig{}, WithoutDefaultPaths(), WithFormat(propertiesFormat{})).
+		Load(t.Context(), File(path))
+	require.NoError(t, err)
+	assert.Equal(t, "from-properties", cfg.Name)
+	assert.Equal(t, ":9090", cfg.Server.Addr)
+}
+
+func TestManagerFormatIsProbedByDefaultPaths(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile("config.properties", []byte("name=probed\n"), 0o600))
+
+	cfg, err := New(formatTestConfig{Name: "default"}, WithFormat(propertiesFormat{})).Load(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, "probed", cfg.Name)
+
+	cfg, err = New(formatTestConfig{Name: "default"}).Load(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, "default", cfg.Name)
+}
+
+func TestRegisterFormatAppliesGlobally(t *testing.T) {
+	restoreGlobalFormats(t)
+	RegisterFormat(propertiesFormat{})
+
+	assert.Contains(t, DefaultPaths(), "config.properties")
+	path := filepath.Join(t.TempDir(), "config.properties")
+	require.NoError(t, InitConfigFile(formatTestConfig{Name: "app"}, path))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "name=app\nserver.addr=\n", string(content))
+
+	cfg, err := New(formatTestConfig{}, WithoutDefaultPaths()).Load(t.Context(), File(path))
+	require.NoError(t, err)
+	assert.Equal(t, "app", cfg.Name)
+}
+
+func TestFileFormatOverridesExtension(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.conf")
+	require.NoError(t, os.WriteFile(path, []byte("name = \"from-toml\"\n"), 0o600))
+	manager := New(formatTestConfig{}, WithoutDefaultPaths())
+
+	cfg, err := manager.Load(t.Context(), File(path, FileFormat("toml")))
+	require.NoError(t, err)
+	assert.Equal(t, "from-toml", cfg.Name)
+
+	_, err = manager.Load(t.Context(), File(path, FileFormat("ini")))
+	require.ErrorContains(t, err, `unknown config format "ini"`)
+}
+
+func TestManagerFormatReplacesBuiltinByName(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("name=replaced\n"), 0o600))
+
+	cfg, err := New(formatTestConfig{}, WithoutDefaultPaths(), WithFormat(renamedFormat{
+		Format: propertiesFormat{}, name: "yaml", extensions: []string{".yaml"},
+	})).Load(t.Context(), File(path))
+	require.NoError(t, err)
+	assert.Equal(t, "replaced", cfg.Name)
+}
+
+func TestConfigFilesWriteExampleUsesFormat(t *testing.T) {
+	dir := t.TempDir()
+	files := ConfigFiles[formatTestConfig]{
+		Manager:     New(formatTestConfig{Name: "app"}, WithFormat(propertiesFormat{})),
+		ExampleFile: filepath.Join(dir, "config.example.properties"),
+		RuntimeFile: filepath.Join(dir, "config.properties"),
+	}
+	files.WriteExample(t)
+	content, err := os.ReadFile(files.ExampleFile)
+	require.NoError(t, err)
+	assert.Equal(t, "name=app\nserver.addr=\n", string(content))
+
+	files.ExampleFile = filepath.Join(dir, "config.example.toml")
+	files.RuntimeFile = filepath.Join(dir, "config.toml")
+	files.WriteExample(t)
+	content, err = os.ReadFile(files.ExampleFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "# 复制此文件为 config.toml 并根据需要修改\n")
+}
+
+func TestInvalidFormatsPanic(t *testing.T) {
+	assert.PanicsWithValue(t, "cfgm: format must not be nil", func() { WithFormat(nil) })
+	assert.PanicsWithError(t, `cfgm: format properties has invalid extension "properties"`, func() {
+		WithFormat(renamedFormat{Format: propertiesFormat{}, name: "properties", extensions: []string{"properties"}})
+	})
+}
+
+type renamedFormat struct {
+	Format
+	name       string
+	extensions []string
+}
+
+func (f renamedFormat) Name() string { return f.name }
+
+func (f renamedFormat) Extensions() []string { return f.extensions }
@@ -0,0 +1,151 @@
+package cfgm
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// WithFS makes file sources, including DefaultPaths and --config, read from
+// fsys instead of the operating system. Sources configured with FromFS keep
+// their own file system.
+func WithFS(fsys fs.FS) Option {
+	if fsys == nil {
+		panic("cfgm: file system must not be nil")
+	}
+	return managerOptionFunc(func(options *managerOptions) {
+		options.fsys = fsys
+	})
+}
+
+// FromFS makes a File, Files, or Dir source read from fsys. Absolute paths
+// are looked up relative to the root of fsys, so /etc/app/config.yaml names
+// etc/app/config.yaml.
+func FromFS(fsys fs.FS) FileOption {
+	if fsys == nil {
+		panic("cfgm: file system must not be nil")
+	}
+	return func(s *fileSource) {
+		s.fsys = fsys
+	}
+}
+
+// withFS returns the schema with fsys as its file system when fsys is set.
+func (s Schema) withFS(fsys fs.FS) Schema {
+	if fsys != nil {
+		s.fsys = fsys
+	}
+	return s
+}
+
+func (s Schema) readFile(path string) ([]byte, error) {
+	if s.fsys == nil {
+		return os.ReadFile(path) //nolint:gosec // path is provided by the caller
+	}
+	name, err := fsName(path)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadFile(s.fsys, name)
+}
+
+func (s Schema) readDir(path string) ([]fs.DirEntry, error) {
+	if s.fsys == nil {
+		return os.ReadDir(path)
+	}
+	name, err := fsName(path)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadDir(s.fsys, name)
+}
+
+// exists reports whether a file or directory exists at path.
+func (s Schema) exists(path string) bool {
+	if s.fsys == nil {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+	name, err := fsName(path)
+	if err != nil {
+		return false
+	}
+	_, err = fs.Stat(s.fsys, name)
+	return err == nil
+}
+
+// glob returns the files matching pattern in lexical order.
+func (s Schema) glob(pattern string) ([]string, error) {
+	if s.fsys == nil {
+		matches, err := filepath.Glob(pattern)
+		slices.Sort(matches)
+		return matches, err
+	}
+	name, err := fsName(pattern)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := fs.Glob(s.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	for index, match := range matches {
+		match = filepath.FromSlash(match)
+		if filepath.IsAbs(pattern) {
+			match = string(filepath.Separator) + match
+		}
+		matches[index] = match
+	}
+	slices.Sort(matches)
+	return matches, nil
+}
+
+// fsName converts an OS-style path to an fs.FS name.
+func fsName(path string) (string, error) {
+	name := strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "/")
+	if name == "" {
+		name = "."
+	}
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: "open", Path: path, Err: fs.ErrInvalid}
+	}
+	return name, nil
+}
+
+// WithDefaultsDocument layers a config document, typically embedded with
+// go:embed, over the defaults passed to New. The result becomes the baseline
+// for flags, examples and every load. name selects the format by its
+// extension as in Bytes and is used in error messages. New panics when the
+// document cannot be parsed or does not match the schema.
+func WithDefaultsDocument(name string, data []byte) Option {
+	return managerOptionFunc(func(options *managerOptions) {
+		options.baselineName = name
+		options.baselineData = bytes.Clone(data)
+		if options.baselineData == nil {
+			options.baselineData = []byte{}
+		}
+	})
+}
+
+func (m *Manager[T]) applyBaseline(name string, data []byte) {
+	schema := Schema{model: m.schema, codecs: m.codecs, formats: m.formats}
+	document, err := decodeNamedBytes(schema, name, "", data)
+	if err == nil {
+		err = m.schema.validateData(document, m.codecs, !m.strictUnknownKeys, m.expandTemplates)
+	}
+	if err != nil {
+		panic(fmt.Sprintf("cfgm: defaults document %s: %v", name, err))
+	}
+
+	configMap := structToMap(m.defaults)
+	mergeMaps(configMap, document)
+	var baseline T
+	if err := decodeConfigMapWithCodecs(configMap, &baseline, m.codecs); err != nil {
+		panic(fmt.Sprintf("cfgm: defaults document %s: %v", name, err))
+	}
+	m.defaults = baseline
+}
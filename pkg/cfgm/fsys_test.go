@@ -0,0 +1,100 @@
+package cfgm
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromFSReadsFilesAndDirs(t *testing.T) {
+	fsys := fstest.MapFS{
+		"etc/app/config.yaml":      {Data: []byte("server:\n  addr: :9000\n")},
+		"etc/app/conf.d/10.yaml":   {Data: []byte("server:\n  workers: 4\n")},
+		"etc/app/conf.d/20.yaml":   {Data: []byte("include: ../extra.json\n")},
+		"etc/app/extra.json":       {Data: []byte(`{"server": {"debug": true}}`)},
+		"etc/app/conf.d/README.md": {Data: []byte("ignored")},
+	}
+	manager := New(bindingDefaults(), WithoutDefaultPaths(), WithIncludes())
+
+	cfg, report, err := manager.LoadReport(t.Context(),
+		File("/etc/app/config.yaml", FromFS(fsys)),
+		Dir("etc/app/conf.d", "", FromFS(fsys)),
+		File("/etc/app/missing.yaml", FromFS(fsys), Optional()),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, ":9000", cfg.Server.Addr)
+	assert.Equal(t, 4, cfg.Server.Workers)
+	assert.True(t, cfg.Server.Debug)
+	assert.Equal(t, "file:/etc/app/config.yaml", report.Sources[0].Name)
+
+	_, report, err = manager.LoadReport(t.Context(), File("/etc/app/conf.d/*.yaml", FromFS(fsys), MergeMatches()))
+	require.NoError(t, err)
+	assert.Equal(t, "file:/etc/app/conf.d/10.yaml", report.Sources[0].Name)
+	assert.Equal(t, "file:/etc/app/extra.json", report.Sources[1].Name)
+
+	_, err = manager.Load(t.Context(), File("/etc/app/missing.yaml", FromFS(fsys)))
+	require.ErrorContains(t, err, "missing.yaml")
+
+	_, err = manager.Load(t.Context(), File("../outside.yaml", FromFS(fsys)))
+	require.ErrorContains(t, err, "invalid argument")
+
+	assert.Panics(t, func() { FromFS(nil) })
+	assert.Panics(t, func() { WithFS(nil) })
+}
+
+func TestManagerWithFSAppliesToDefaultPathsAndConfigFlag(t *testing.T) {
+	fsys := fstest.MapFS{
+		"etc/app/config.yaml": {Data: []byte("server:\n  addr: :9000\n")},
+		"override.json":       {Data: []byte(`{"server": {"workers": 6}}`)},
+	}
+	manager := New(bindingDefaults(), AppName("app"), WithFS(fsys))
+
+	cfg, err := manager.Load(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, ":9000", cfg.Server.Addr)
+
+	cfg, err = runManagerWithRootArgs(t, manager, []string{"--config", "override.json"})
+	require.NoError(t, err)
+	assert.Equal(t, ":9000", cfg.Server.Addr)
+	assert.Equal(t, 6, cfg.Server.Workers)
+
+	cfg, err = manager.Load(t.Context(), File("other.yaml", FromFS(fstest.MapFS{
+		"other.yaml": {Data: []byte("server:\n  addr: :7000\n")},
+	})))
+	require.NoError(t, err)
+	assert.Equal(t, ":7000", cfg.Server.Addr, "FromFS overrides WithFS")
+}
+
+func TestWithDefaultsDocument(t *testing.T) {
+	document := []byte("server:\n  addr: :9000\n  tags: [embedded]\n  redis:\n    password: ${REDIS_PASSWORD}\n")
+	t.Setenv("REDIS_PASSWORD", "pw")
+	manager := New(bindingDefaults(), WithoutDefaultPaths(), WithDefaultsDocument("defaults.yaml", document))
+
+	cfg, report, err := manager.LoadReport(t.Context(), Set("server.workers=3"))
+	require.NoError(t, err)
+	assert.Equal(t, ":9000", cfg.Server.Addr)
+	assert.Equal(t, []string{"embedded"}, cfg.Server.Tags)
+	assert.Equal(t, 3, cfg.Server.Workers)
+	assert.Equal(t, "redis://localhost:6379", cfg.Server.Redis.URL)
+	assert.Equal(t, "pw", cfg.Server.Redis.Password)
+	assert.Len(t, report.Sources, 1)
+
+	cfg, err = runManagerWithRootArgs(t, manager, nil)
+	require.NoError(t, err)
+	assert.Equal(t, ":9000", cfg.Server.Addr)
+
+	assert.PanicsWithValue(t, "cfgm: defaults document defaults.yaml: unknown config keys:\n  - server.typo", func() {
+		New(bindingDefaults(), WithDefaultsDocument("defaults.yaml", []byte("server:\n  typo: 1\n")))
+	})
+	assert.Panics(t, func() {
+		New(bindingDefaults(), WithDefaultsDocument("defaults.json", []byte("{bad")))
+	})
+	assert.Panics(t, func() {
+		New(bindingDefaults(), WithDefaultsDocument("defaults.yaml", []byte("server:\n  workers: many\n")))
+	})
+	assert.NotPanics(t, func() {
+		New(bindingDefaults(), AllowUnknownKeys(), WithDefaultsDocument("defaults.yaml", []byte("server:\n  typo: 1\n")))
+	})
+}
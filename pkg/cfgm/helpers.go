@@ -1,15 +1,12 @@
 package cfgm
 
 import (
-	"encoding/json"
-	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"time"
-
-	yamlv3 "go.yaml.in/yaml/v3"
 )
 
 var (
@@ -52,16 +49,25 @@ func configFields(typ reflect.Type) ([]configField, []reflect.Type) {
 	return fields, inlinedTypes
 }
 
-func configFieldTag(field reflect.StructField) (string, bool) {
-	key := configTagName(field)
+// cfgmTagOption returns the option of a cfgm:",inline" or cfgm:",secret"
+// tag, or "" when the field has no cfgm tag.
+func cfgmTagOption(field reflect.StructField) string {
 	tag := field.Tag.Get("cfgm")
 	if tag == "" {
-		return key, false
+		return ""
 	}
 	parts := strings.Split(tag, ",")
-	if len(parts) != 2 || parts[0] != "" || parts[1] != "inline" {
+	if len(parts) != 2 || parts[0] != "" || (parts[1] != "inline" && parts[1] != "secret") {
 		panic(fmt.Errorf("cfgm: config field %s has invalid cfgm tag %q", field.Name, tag))
 	}
+	return parts[1]
+}
+
+func configFieldTag(field reflect.StructField) (string, bool) {
+	key := configTagName(field)
+	if cfgmTagOption(field) != "inline" {
+		return key, false
+	}
 	if key != "" {
 		panic(fmt.Errorf("cfgm: inline config field %s must not have a name", field.Name))
 	}
@@ -180,34 +186,6 @@ func valueToAny(val reflect.Value, typ reflect.Type) any {
 	}
 }
 
-func parseConfigBytes(path string, content []byte) (map[string]any, error) {
-	var raw any
-	var err error
-	if isJSONPath(path) {
-		err = json.Unmarshal(content, &raw)
-	} else {
-		err = yamlv3.Unmarshal(content, &raw)
-	}
-	if err != nil {
-		return nil, err
-	}
-
-	normalized := normalizeMapKeys(raw)
-	if normalized == nil {
-		return map[string]any{}, nil
-	}
-	configMap, ok := normalized.(map[string]any)
-	if !ok {
-		return nil, errors.New("config root must be object")
-	}
-
-	return configMap, nil
-}
-
-func isJSONPath(path string) bool {
-	return strings.EqualFold(filepath.Ext(path), ".json")
-}
-
 func normalizeMapKeys(val any) any {
 	switch typed := val.(type) {
 	case map[string]any:
@@ -265,3 +243,25 @@ func setByPath(dst map[string]any, path string, value any) {
 		current = next
 	}
 }
+
+// writeFileAtomic replaces path with data through a temporary file in the
+// same directory.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(data); err != nil {
+		_ = temp.Close()
+		return err
+	}
+	if err := temp.Chmod(perm); err != nil {
+		_ = temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
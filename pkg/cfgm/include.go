@@ -0,0 +1,95 @@
+package cfgm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// includeKey is the top-level key read by WithIncludes.
+const includeKey = "include"
+
+// maxIncludeDepth bounds nested includes below the file named by a source.
+const maxIncludeDepth = 16
+
+// loadIncludes returns the layers of one config file: every included file in
+// order, each preceded by its own includes, followed by the file's own keys
+// under name. stack holds the absolute paths of the including files.
+func loadIncludes(
+	ctx context.Context, schema Schema, name, path string, data map[string]any, stack []string,
+) ([]sourceLayer, error) {
+	raw, exists := data[includeKey]
+	if !schema.includes || !exists {
+		return []sourceLayer{{name: name, data: data, origin: valueOrigin{file: path}}}, nil
+	}
+	delete(data, includeKey)
+
+	includes, err := includePaths(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	stack = append(slices.Clip(stack), absPath)
+	if len(stack) > maxIncludeDepth {
+		return nil, fmt.Errorf("%s: include depth exceeds %d", path, maxIncludeDepth)
+	}
+
+	var layers []sourceLayer
+	for _, include := range includes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+		absInclude, err := filepath.Abs(include)
+		if err != nil {
+			return nil, err
+		}
+		if index := slices.Index(stack, absInclude); index >= 0 {
+			cycle := append(slices.Clone(stack[index:]), absInclude)
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(cycle, " -> "))
+		}
+
+		included, err := readConfigFile(schema, include, "")
+		if err != nil {
+			return nil, fmt.Errorf("%s: include: %w", path, err)
+		}
+		nested, err := loadIncludes(ctx, schema, "file:"+include, include, included, stack)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, nested...)
+	}
+
+	return append(layers, sourceLayer{name: name, data: data, origin: valueOrigin{file: path}}), nil
+}
+
+func includePaths(raw any) ([]string, error) {
+	errInvalid := errors.New("include must be a path or a list of paths")
+	switch typed := raw.(type) {
+	case string:
+		if typed == "" {
+			return nil, errInvalid
+		}
+		return []string{typed}, nil
+	case []any:
+		paths := make([]string, 0, len(typed))
+		for _, item := range typed {
+			path, ok := item.(string)
+			if !ok || path == "" {
+				return nil, errInvalid
+			}
+			paths = append(paths, path)
+		}
+		return paths, nil
+	default:
+		return nil, errInvalid
+	}
+}
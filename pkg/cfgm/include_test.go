@@ -0,0 +1,120 @@
+package cfgm
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type includeTestConfig struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+	Server   struct {
+		Addr string `json:"addr"`
+		Port int    `json:"port"`
+	} `json:"server"`
+}
+
+func TestIncludesMergeBeforeIncludingFile(t *testing.T) {
+	dir := writeConfigDir(t, map[string]string{
+		"config.yaml":          "include: [base.yaml, secrets/secrets.toml]\nname: main\n",
+		"base.yaml":            "include: common.json\nname: base\nserver:\n  addr: :8080\n",
+		"common.json":          `{"server": {"addr": ":1", "port": 80}}`,
+		"secrets/secrets.toml": "password = \"s3cret\"\nname = \"secrets\"\n",
+	})
+	path := filepath.Join(dir, "config.yaml")
+
+	cfg, report, err := New(includeTestConfig{}, WithoutDefaultPaths(), WithIncludes()).LoadReport(t.Context(), File(path))
+	require.NoError(t, err)
+	assert.Equal(t, "main", cfg.Name)
+	assert.Equal(t, "s3cret", cfg.Password)
+	assert.Equal(t, ":8080", cfg.Server.Addr)
+	assert.Equal(t, 80, cfg.Server.Port)
+	assert.Equal(t, []SourceReport{
+		{Name: "file:" + filepath.Join(dir, "common.json"), Keys: []string{"server.addr", "server.port"}},
+		{Name: "file:" + filepath.Join(dir, "base.yaml"), Keys: []string{"name", "server.addr"}},
+		{Name: "file:" + filepath.Join(dir, "secrets/secrets.toml"), Keys: []string{"name", "password"}},
+		{Name: "file:" + path, Keys: []string{"name"}},
+	}, report.Sources)
+}
+
+func TestIncludesAreDisabledByDefault(t *testing.T) {
+	path := writeTempConfig(t, "include: base.yaml\n")
+	_, err := New(includeTestConfig{}, WithoutDefaultPaths()).Load(t.Context(), File(path))
+	require.ErrorContains(t, err, "include")
+}
+
+func TestIncludeValidationErrorNamesIncludedFile(t *testing.T) {
+	dir := writeConfigDir(t, map[string]string{
+		"config.yaml": "include: bad.yaml\n",
+		"bad.yaml":    "server:\n  typo: 1\n",
+	})
+
+	_, err := New(includeTestConfig{}, WithoutDefaultPaths(), WithIncludes()).Load(t.Context(), File(filepath.Join(dir, "config.yaml")))
+	require.ErrorContains(t, err, "file:"+filepath.Join(dir, "bad.yaml"))
+	require.ErrorContains(t, err, "server.typo")
+}
+
+func TestIncludeErrors(t *testing.T) {
+	manager := New(includeTestConfig{}, WithoutDefaultPaths(), WithIncludes())
+
+	t.Run("cycle", func(t *testing.T) {
+		dir := writeConfigDir(t, map[string]string{
+			"a.yaml": "include: b.yaml\n",
+			"b.yaml": "include: [c.yaml]\n",
+			"c.yaml": "include: a.yaml\n",
+		})
+		_, err := manager.Load(t.Context(), File(filepath.Join(dir, "a.yaml")))
+		require.ErrorContains(t, err, "include cycle: "+filepath.Join(dir, "a.yaml")+" -> "+
+			filepath.Join(dir, "b.yaml")+" -> "+filepath.Join(dir, "c.yaml")+" -> "+filepath.Join(dir, "a.yaml"))
+	})
+
+	t.Run("depth", func(t *testing.T) {
+		files := map[string]string{}
+		for i := range maxIncludeDepth + 1 {
+			files[filepath.Join("nested", string(rune('a'+i))+".yaml")] = "include: " + string(rune('a'+i+1)) + ".yaml\n"
+		}
+		files[filepath.Join("nested", string(rune('a'+maxIncludeDepth+1))+".yaml")] = "name: deep\n"
+		dir := writeConfigDir(t, files)
+		_, err := manager.Load(t.Context(), File(filepath.Join(dir, "nested", "a.yaml")))
+		require.ErrorContains(t, err, "include depth exceeds 16")
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		path := writeTempConfig(t, "include: missing.yaml\n")
+		_, err := manager.Load(t.Context(), File(path))
+		require.ErrorContains(t, err, path+": include:")
+		require.ErrorContains(t, err, "missing.yaml")
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		path := writeTempConfig(t, "include: {a: b}\n")
+		_, err := manager.Load(t.Context(), File(path))
+		require.ErrorContains(t, err, "include must be a path or a list of paths")
+	})
+}
+
+func TestIncludesInDirFragments(t *testing.T) {
+	dir := writeConfigDir(t, map[string]string{
+		"conf.d/10-app.yaml": "include: ../shared.yaml\nname: app\n",
+		"shared.yaml":        "name: shared\npassword: x\n",
+	})
+
+	cfg, report, err := New(includeTestConfig{}, WithoutDefaultPaths(), WithIncludes()).
+		LoadReport(t.Context(), Dir(filepath.Join(dir, "conf.d"), ""))
+	require.NoError(t, err)
+	assert.Equal(t, "app", cfg.Name)
+	assert.Equal(t, "x", cfg.Password)
+	require.Len(t, report.Sources, 2)
+	assert.Equal(t, "file:"+filepath.Join(dir, "conf.d", "../shared.yaml"), report.Sources[0].Name)
+}
+
+func TestWithIncludesRejectsIncludeField(t *testing.T) {
+	type Config struct {
+		Include []string `json:"include"`
+	}
+	assert.Panics(t, func() { New(Config{}, WithIncludes()) })
+	assert.NotPanics(t, func() { New(Config{}) })
+}
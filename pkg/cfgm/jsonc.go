@@ -0,0 +1,566 @@
+package cfgm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// decodeJSONC parses JSON with comments. It accepts the JSON5 relaxations
+// operators reach for most: // and /* */ comments, trailing commas,
+// unquoted identifier keys, single-quoted strings, hexadecimal numbers,
+// leading or trailing decimal points, explicit plus signs, Infinity, NaN,
+// and backslash line continuations. Numbers decode as float64, like
+// encoding/json.
+func decodeJSONC(content []byte) (any, error) {
+	if !utf8.Valid(content) {
+		return nil, errors.New("jsonc: document must be valid UTF-8")
+	}
+	p := &jsoncParser{text: string(content)}
+	p.skipSpace()
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.eof() {
+		return nil, nil
+	}
+	value, err := p.parseValue(0)
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.err != nil {
+		return nil, p.err
+	}
+	if !p.eof() {
+		return nil, p.errorf("unexpected %q after top-level value", p.peek())
+	}
+	return value, nil
+}
+
+type jsoncParser struct {
+	text   string
+	offset int
+	err    error
+}
+
+const maxJSONCDepth = 1000
+
+func (p *jsoncParser) errorf(format string, args ...any) error {
+	line, column := textPosition(p.text, p.offset)
+	return fmt.Errorf("jsonc: line %d, column %d: %s", line, column, fmt.Sprintf(format, args...))
+}
+
+// textPosition converts a byte offset into one-based line and column numbers.
+func textPosition(text string, offset int) (int, int) {
+	offset = min(offset, len(text))
+	line := 1 + strings.Count(text[:offset], "\n")
+	column := offset - strings.LastIndex(text[:offset], "\n")
+	return line, column
+}
+
+func (p *jsoncParser) eof() bool { return p.offset >= len(p.text) }
+
+func (p *jsoncParser) peek() byte { return p.text[p.offset] }
+
+func (p *jsoncParser) skipSpace() {
+	for !p.eof() {
+		switch {
+		case strings.HasPrefix(p.text[p.offset:], "//"):
+			end := strings.IndexByte(p.text[p.offset:], '\n')
+			if end < 0 {
+				p.offset = len(p.text)
+				return
+			}
+			p.offset += end + 1
+		case strings.HasPrefix(p.text[p.offset:], "/*"):
+			end := strings.Index(p.text[p.offset+2:], "*/")
+			if end < 0 {
+				p.err = p.errorf("unterminated block comment")
+				p.offset = len(p.text)
+				return
+			}
+			p.offset += end + 4
+		default:
+			r, width := utf8.DecodeRuneInString(p.text[p.offset:])
+			if !unicode.IsSpace(r) && r != '\uFEFF' {
+				return
+			}
+			p.offset += width
+		}
+	}
+}
+
+func (p *jsoncParser) parseValue(depth int) (any, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if depth > maxJSONCDepth {
+		return nil, p.errorf("maximum nesting depth exceeded")
+	}
+	if p.eof() {
+		return nil, p.errorf("unexpected end of input")
+	}
+	switch ch := p.peek(); {
+	case ch == '{':
+		return p.parseObject(depth)
+	case ch == '[':
+		return p.parseArray(depth)
+	case ch == '"' || ch == '\'':
+		return p.parseString()
+	case ch == '-' || ch == '+' || ch == '.' || isDigit(ch):
+		return p.parseNumber()
+	default:
+		word := p.parseIdentifier()
+		switch word {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "NaN":
+			return math.NaN(), nil
+		case "":
+			return nil, p.errorf("unexpected %q", ch)
+		default:
+			return nil, p.errorf("unexpected identifier %q", word)
+		}
+	}
+}
+
+func (p *jsoncParser) parseObject(depth int) (map[string]any, error) {
+	p.offset++
+	object := map[string]any{}
+	for {
+		p.skipSpace()
+		if p.err != nil {
+			return nil, p.err
+		}
+		if p.eof() {
+			return nil, p.errorf("unterminated object")
+		}
+		if p.peek() == '}' {
+			p.offset++
+			return object, nil
+		}
+		var key string
+		var err error
+		if ch := p.peek(); ch == '"' || ch == '\'' {
+			key, err = p.parseString()
+		} else if key = p.parseIdentifier(); key == "" {
+			err = p.errorf("expected object key, found %q", ch)
+		}
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.eof() || p.peek() != ':' {
+			return nil, p.errorf("expected ':' after key %q", key)
+		}
+		p.offset++
+		p.skipSpace()
+		value, err := p.parseValue(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		object[key] = value
+		p.skipSpace()
+		if p.eof() {
+			return nil, p.errorf("unterminated object")
+		}
+		switch p.peek() {
+		case ',':
+			p.offset++
+		case '}':
+			p.offset++
+			return object, nil
+		default:
+			return nil, p.errorf("expected ',' or '}' after object value, found %q", p.peek())
+		}
+	}
+}
+
+func (p *jsoncParser) parseArray(depth int) ([]any, error) {
+	p.offset++
+	items := []any{}
+	for {
+		p.skipSpace()
+		if p.err != nil {
+			return nil, p.err
+		}
+		if p.eof() {
+			return nil, p.errorf("unterminated array")
+		}
+		if p.peek() == ']' {
+			p.offset++
+			return items, nil
+		}
+		value, err := p.parseValue(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, value)
+		p.skipSpace()
+		if p.eof() {
+			return nil, p.errorf("unterminated array")
+		}
+		switch p.peek() {
+		case ',':
+			p.offset++
+		case ']':
+			p.offset++
+			return items, nil
+		default:
+			return nil, p.errorf("expected ',' or ']' after array value, found %q", p.peek())
+		}
+	}
+}
+
+func (p *jsoncParser) parseIdentifier() string {
+	start := p.offset
+	for !p.eof() {
+		r, width := utf8.DecodeRuneInString(p.text[p.offset:])
+		if r != '_' && r != '$' && !unicode.IsLetter(r) && (p.offset == start || !unicode.IsDigit(r)) {
+			break
+		}
+		p.offset += width
+	}
+	return p.text[start:p.offset]
+}
+
+func (p *jsoncParser) parseString() (string, error) {
+	quote := p.peek()
+	start := p.offset
+	p.offset++
+	var out strings.Builder
+	for {
+		if p.eof() {
+			p.offset = start
+			return "", p.errorf("unterminated string")
+		}
+		ch := p.peek()
+		switch {
+		case ch == quote:
+			p.offset++
+			return out.String(), nil
+		case ch == '\n':
+			return "", p.errorf("newline in string")
+		case ch == '\\':
+			if err := p.parseEscape(&out); err != nil {
+				return "", err
+			}
+		default:
+			r, width := utf8.DecodeRuneInString(p.text[p.offset:])
+			out.WriteRune(r)
+			p.offset += width
+		}
+	}
+}
+
+func (p *jsoncParser) parseEscape(out *strings.Builder) error {
+	if p.offset+1 >= len(p.text) {
+		return p.errorf("unterminated escape sequence")
+	}
+	escape := p.text[p.offset+1]
+	p.offset += 2
+	switch escape {
+	case 'b':
+		out.WriteByte('\b')
+	case 'f':
+		out.WriteByte('\f')
+	case 'n':
+		out.WriteByte('\n')
+	case 'r':
+		out.WriteByte('\r')
+	case 't':
+		out.WriteByte('\t')
+	case 'v':
+		out.WriteByte('\v')
+	case '0':
+		out.WriteByte(0)
+	case '\n':
+		// Line continuation.
+	case '\r':
+		if !p.eof() && p.peek() == '\n' {
+			p.offset++
+		}
+	case 'u':
+		r, err := p.parseUnicodeEscape()
+		if err != nil {
+			return err
+		}
+		if utf16.IsSurrogate(r) && strings.HasPrefix(p.text[p.offset:], `\u`) {
+			p.offset += 2
+			low, err := p.parseUnicodeEscape()
+			if err != nil {
+				return err
+			}
+			r = utf16.DecodeRune(r, low)
+		}
+		out.WriteRune(r)
+	default:
+		// Any other character stands for itself; decode it whole so escaped
+		// multi-byte characters are not split.
+		r, size := utf8.DecodeRuneInString(p.text[p.offset-1:])
+		out.WriteRune(r)
+		p.offset += size - 1
+	}
+	return nil
+}
+
+func (p *jsoncParser) parseUnicodeEscape() (rune, error) {
+	if p.offset+4 > len(p.text) {
+		return 0, p.errorf("invalid unicode escape")
+	}
+	code, err := strconv.ParseUint(p.text[p.offset:p.offset+4], 16, 32)
+	if err != nil {
+		return 0, p.errorf("invalid unicode escape %q", p.text[p.offset:p.offset+4])
+	}
+	p.offset += 4
+	return rune(code), nil
+}
+
+func (p *jsoncParser) parseNumber() (float64, error) {
+	start := p.offset
+	negative := false
+	if ch := p.peek(); ch == '+' || ch == '-' {
+		negative = ch == '-'
+		p.offset++
+	}
+	switch word := p.text[p.offset:]; {
+	case strings.HasPrefix(word, "Infinity"):
+		p.offset += len("Infinity")
+		if negative {
+			return math.Inf(-1), nil
+		}
+		return math.Inf(1), nil
+	case strings.HasPrefix(word, "NaN"):
+		p.offset += len("NaN")
+		return math.NaN(), nil
+	case strings.HasPrefix(word, "0x") || strings.HasPrefix(word, "0X"):
+		p.offset += 2
+		digitsStart := p.offset
+		for !p.eof() && strings.IndexByte("0123456789abcdefABCDEF", p.peek()) >= 0 {
+			p.offset++
+		}
+		value, err := strconv.ParseUint(p.text[digitsStart:p.offset], 16, 64)
+		if err != nil {
+			p.offset = start
+			return 0, p.errorf("invalid hexadecimal number")
+		}
+		if negative {
+			return -float64(value), nil
+		}
+		return float64(value), nil
+	}
+	for !p.eof() && (isDigit(p.peek()) || strings.IndexByte(".eE+-", p.peek()) >= 0) {
+		if ch := p.peek(); (ch == '+' || ch == '-') && !strings.ContainsAny(p.text[p.offset-1:p.offset], "eE") {
+			break
+		}
+		p.offset++
+	}
+	token := strings.TrimPrefix(p.text[start:p.offset], "+")
+	value, err := strconv.ParseFloat(token, 64)
+	if err != nil || strings.HasSuffix(token, "e") || strings.HasSuffix(token, "E") {
+		p.offset = start
+		return 0, p.errorf("invalid number %q", p.text[start:max(p.offset, start+1)])
+	}
+	return value, nil
+}
+
+// jsonSyntaxHint explains JSON errors caused by JSONC syntax in .json files.
+func jsonSyntaxHint(content []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		return err
+	}
+	// Offset counts the bytes read, including the offending one.
+	line, column := textPosition(string(content), max(int(syntaxErr.Offset)-1, 0))
+	err = fmt.Errorf("line %d, column %d: %w", line, column, err)
+	if _, jsoncErr := decodeJSONC(content); jsoncErr == nil {
+		return fmt.Errorf("%w (the file contains comments or trailing commas; use the .jsonc extension or FileFormat(\"jsonc\"))", err)
+	}
+	return err
+}
+
+type jsoncFormat struct {
+	name       string
+	extensions []string
+}
+
+func (f jsoncFormat) Name() string { return f.name }
+
+func (f jsoncFormat) Extensions() []string { return f.extensions }
+
+func (jsoncFormat) Decode(data []byte) (map[string]any, error) {
+	raw, err := decodeJSONC(data)
+	if err != nil {
+		return nil, err
+	}
+	return configRoot(raw)
+}
+
+func (jsoncFormat) Encode(data map[string]any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeJSONCValue(&buf, mapToOrderedFields(data), ""); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+func (jsoncFormat) example(val reflect.Value, typ reflect.Type, runtimeFile string) []byte {
+	return exampleJSONC(val, typ, runtimeFile)
+}
+
+func writeJSONCComment(buf *bytes.Buffer, comment, indent string) {
+	for line := range strings.SplitSeq(comment, "\n") {
+		if line == "" {
+			buf.WriteString(indent + "//\n")
+			continue
+		}
+		buf.WriteString(indent + "// " + line + "\n")
+	}
+}
+
+// writeJSONCValue writes value at the current position. Objects and arrays
+// that contain objects span lines; other arrays stay on one line.
+func writeJSONCValue(buf *bytes.Buffer, value any, indent string) error {
+	switch typed := value.(type) {
+	case []orderedField:
+		return writeJSONCObject(buf, typed, indent)
+	case []any:
+		if !slicesContainObjects(typed) {
+			text, err := jsoncInlineValue(typed)
+			if err != nil {
+				return err
+			}
+			buf.WriteString(text)
+			return nil
+		}
+		buf.WriteString("[\n")
+		for index, item := range typed {
+			buf.WriteString(indent + "  ")
+			if err := writeJSONCValue(buf, item, indent+"  "); err != nil {
+				return err
+			}
+			if index < len(typed)-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + "]")
+		return nil
+	default:
+		text, err := jsoncInlineValue(value)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(text)
+		return nil
+	}
+}
+
+func writeJSONCObject(buf *bytes.Buffer, fields []orderedField, indent string) error {
+	if len(fields) == 0 {
+		buf.WriteString("{}")
+		return nil
+	}
+	buf.WriteString("{\n")
+	inner := indent + "  "
+	for index, field := range fields {
+		_, object := field.value.([]orderedField)
+		_, array := field.value.([]any)
+		complexValue := object || array || strings.Contains(field.comment, "\n")
+		if complexValue && field.comment != "" {
+			if index > 0 {
+				buf.WriteString("\n")
+			}
+			writeJSONCComment(buf, field.comment, inner)
+		}
+		key, err := jsoncInlineValue(field.key)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(inner + key + ": ")
+		if err := writeJSONCValue(buf, field.value, inner); err != nil {
+			return fmt.Errorf("%s: %w", field.key, err)
+		}
+		if index < len(fields)-1 {
+			buf.WriteString(",")
+		}
+		if !complexValue && field.comment != "" {
+			buf.WriteString(" // " + field.comment)
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString(indent + "}")
+	return nil
+}
+
+func slicesContainObjects(items []any) bool {
+	for _, item := range items {
+		switch typed := item.(type) {
+		case []orderedField:
+			return true
+		case []any:
+			if slicesContainObjects(typed) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func jsoncInlineValue(value any) (string, error) {
+	switch typed := value.(type) {
+	case []orderedField:
+		var buf bytes.Buffer
+		if err := writeJSONCObject(&buf, typed, ""); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	case []any:
+		parts := make([]string, len(typed))
+		for index, item := range typed {
+			text, err := jsoncInlineValue(item)
+			if err != nil {
+				return "", err
+			}
+			parts[index] = text
+		}
+		return "[" + strings.Join(parts, ", ") + "]", nil
+	case time.Time:
+		value = typed.Format(time.RFC3339Nano)
+	case time.Duration:
+		value = typed.String()
+	case float64:
+		switch {
+		case math.IsInf(typed, 1):
+			return "Infinity", nil
+		case math.IsInf(typed, -1):
+			return "-Infinity", nil
+		case math.IsNaN(typed):
+			return "NaN", nil
+		}
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
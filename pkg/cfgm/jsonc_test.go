@@ -0,0 +1,144 @@
+package cfgm
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeJSONC(t *testing.T) {
+	raw, err := decodeJSONC([]byte(`// leading comment
+{
+  /* block
+     comment */
+  name: 'single \'quoted\'',
+  "emoji": "😀",
+  escaped: "\é\😀",
+  $id: 0x1F,
+  ratio: .5,
+  count: +3,
+  huge: -Infinity,
+  multi: "a\
+b",
+  list: [1, 2, 3,],
+  nested: {enabled: true, value: null,},
+}
+`))
+	require.NoError(t, err)
+	data, ok := raw.(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "single 'quoted'", data["name"])
+	assert.Equal(t, "😀", data["emoji"])
+	assert.Equal(t, "é😀", data["escaped"])
+	assert.InDelta(t, 31, data["$id"], 0)
+	assert.InDelta(t, 0.5, data["ratio"], 0)
+	assert.InDelta(t, 3, data["count"], 0)
+	assert.True(t, math.IsInf(data["huge"].(float64), -1))
+	assert.Equal(t, "ab", data["multi"])
+	assert.Equal(t, []any{float64(1), float64(2), float64(3)}, data["list"])
+	assert.Equal(t, map[string]any{"enabled": true, "value": nil}, data["nested"])
+}
+
+func TestDecodeJSONCReportsPositions(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		message string
+	}{
+		{name: "missing comma", content: "{\n  a: 1\n  b: 2\n}", message: "line 3, column 3: expected ',' or '}'"},
+		{name: "unterminated comment", content: "{} /* open", message: "unterminated block comment"},
+		{name: "unterminated string", content: "{\n  a: 'x\n}", message: "line 2, column 8: newline in string"},
+		{name: "bad identifier", content: "{a: yes}", message: `unexpected identifier "yes"`},
+		{name: "trailing value", content: "{} {}", message: "after top-level value"},
+		{name: "missing value", content: "{a: }", message: `unexpected '}'`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := decodeJSONC([]byte(test.content))
+			require.ErrorContains(t, err, test.message)
+		})
+	}
+}
+
+func TestManagerExplainsJSONCInJSONFiles(t *testing.T) {
+	type Config struct {
+		Name string `json:"name"`
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	content := []byte("{\n  // operator note\n  \"name\": \"app\",\n}\n")
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+
+	manager := New(Config{}, WithoutDefaultPaths())
+	_, err := manager.Load(t.Context(), File(path))
+	require.ErrorContains(t, err, "line 2, column 3")
+	require.ErrorContains(t, err, "use the .jsonc extension")
+
+	cfg, err := manager.Load(t.Context(), File(path, FileFormat("jsonc")))
+	require.NoError(t, err)
+	assert.Equal(t, "app", cfg.Name)
+}
+
+func TestExampleJSONCLoadsBackIntoDefaults(t *testing.T) {
+	type Provider struct {
+		Issuer string `json:"issuer" desc:"Issuer URL"`
+	}
+	type Config struct {
+		Name      string            `json:"name"      desc:"Name"`
+		Note      string            `json:"note"      desc:"Line one\nline two"`
+		Timeout   time.Duration     `json:"timeout"   desc:"Timeout"`
+		Tags      []string          `json:"tags"      desc:"Tags"`
+		Labels    map[string]string `json:"labels"    desc:"Labels"`
+		Provider  *Provider         `json:"provider"  desc:"Optional provider"`
+		Providers []Provider        `json:"providers" desc:"Providers"`
+	}
+	defaults := Config{
+		Name:      "app",
+		Note:      "x",
+		Timeout:   time.Minute,
+		Tags:      []string{"a", "b"},
+		Labels:    map[string]string{"region": "cn"},
+		Providers: []Provider{{Issuer: "https://a"}},
+	}
+	example := ExampleJSONC(defaults)
+	assert.Equal(t, `// 默认配置示例文件, 此文件由单元测试生成, 请勿直接修改
+// 复制此文件为 config.jsonc 并根据需要修改
+{
+  "name": "app", // Name
+
+  // Line one
+  // line two
+  "note": "x",
+  "timeout": "1m0s", // Timeout
+
+  // Tags
+  "tags": ["a", "b"],
+
+  // Labels
+  "labels": {
+    "region": "cn"
+  },
+  "provider": null, // Optional provider
+
+  // Providers
+  "providers": [
+    {
+      "issuer": "https://a" // Issuer URL
+    }
+  ]
+}
+`, string(example))
+
+	for _, name := range []string{"config.jsonc", "config.json5"} {
+		path := filepath.Join(t.TempDir(), name)
+		require.NoError(t, os.WriteFile(path, example, 0o600))
+		cfg, err := New(Config{}, WithoutDefaultPaths()).Load(t.Context(), File(path))
+		require.NoError(t, err)
+		assert.Equal(t, defaults, *cfg)
+	}
+}
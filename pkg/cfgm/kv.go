@@ -0,0 +1,278 @@
+package cfgm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// KVStore is a hierarchical key/value store in the style of Consul or etcd.
+// Keys use "/" as separator.
+type KVStore interface {
+	// Get returns the value of key and whether it exists.
+	Get(ctx context.Context, key string) (string, bool, error)
+	// List returns every pair whose key starts with prefix.
+	List(ctx context.Context, prefix string) (map[string]string, error)
+}
+
+// KVWatcher is implemented by stores that can report changes. The returned
+// channel receives a value after keys under prefix change and is closed when
+// ctx is done. Callers reload the Manager to pick up the change.
+type KVWatcher interface {
+	Watch(ctx context.Context, prefix string) (<-chan struct{}, error)
+}
+
+type kvSource struct {
+	store  KVStore
+	prefix string
+}
+
+// KV loads the keys under prefix from store. The remainder of each key names
+// a config path, so with prefix "app" the key app/server/addr sets
+// server.addr. Values are parsed like Env values; keys that name no config
+// field are validated like unknown file keys.
+func KV(store KVStore, prefix string) Source {
+	if store == nil {
+		panic("cfgm: KV store must not be nil")
+	}
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	return &kvSource{store: store, prefix: prefix}
+}
+
+func (s *kvSource) Name() string {
+	return "kv:" + s.prefix
+}
+
+func (s *kvSource) Load(ctx context.Context, schema Schema) (map[string]any, error) {
+	pairs, err := s.store.List(ctx, s.prefix)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]Field)
+	for _, field := range schema.Fields() {
+		fields[field.Path] = field
+	}
+
+	out := map[string]any{}
+	// leaves and parents map the paths set so far, and their parents, to a
+	// key that produced them, so keys such as app/server and app/server/addr
+	// are reported instead of overwriting each other.
+	leaves := make(map[string]string)
+	parents := make(map[string]string)
+	for _, key := range slices.Sorted(maps.Keys(pairs)) {
+		relative := strings.Trim(strings.TrimPrefix(key, s.prefix), "/")
+		if !strings.HasPrefix(key, s.prefix) || relative == "" {
+			continue
+		}
+		path := strings.ReplaceAll(relative, "/", ".")
+		if other, ok := leaves[path]; ok {
+			return nil, fmt.Errorf("%s: conflicts with %s", key, other)
+		}
+		if other, ok := parents[path]; ok {
+			return nil, fmt.Errorf("%s: conflicts with %s, which is nested below it", key, other)
+		}
+		for parent := path; strings.Contains(parent, "."); {
+			parent = parent[:strings.LastIndex(parent, ".")]
+			if other, ok := leaves[parent]; ok {
+				return nil, fmt.Errorf("%s: conflicts with %s, which sets its parent", key, other)
+			}
+			if _, ok := parents[parent]; !ok {
+				parents[parent] = key
+			}
+		}
+		leaves[path] = key
+		field, ok := fields[path]
+		if !ok {
+			setByPath(out, path, pairs[key])
+			continue
+		}
+		parsed, err := schema.parseEnvValue(field, pairs[key])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		setByPath(out, path, parsed)
+	}
+
+	return out, nil
+}
+
+// MemoryStore is an in-memory KVStore and KVWatcher for tests and local
+// development. The zero value is ready to use.
+type MemoryStore struct {
+	mu       sync.Mutex
+	values   map[string]string
+	watchers map[chan struct{}]string
+}
+
+// NewMemoryStore returns a MemoryStore holding a copy of values.
+func NewMemoryStore(values map[string]string) *MemoryStore {
+	return &MemoryStore{values: maps.Clone(values)}
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.values[key]
+	return value, ok, nil
+}
+
+func (s *MemoryStore) List(_ context.Context, prefix string) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return listKVPrefix(s.values, prefix), nil
+}
+
+// Set stores value under key and notifies matching watchers.
+func (s *MemoryStore) Set(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.values == nil {
+		s.values = make(map[string]string)
+	}
+	s.values[key] = value
+	s.notify(key)
+}
+
+// Delete removes key and notifies matching watchers.
+func (s *MemoryStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.values[key]; !ok {
+		return
+	}
+	delete(s.values, key)
+	s.notify(key)
+}
+
+func (s *MemoryStore) Watch(ctx context.Context, prefix string) (<-chan struct{}, error) {
+	changes := make(chan struct{}, 1)
+	s.mu.Lock()
+	if s.watchers == nil {
+		s.watchers = make(map[chan struct{}]string)
+	}
+	s.watchers[changes] = prefix
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		delete(s.watchers, changes)
+		close(changes)
+		s.mu.Unlock()
+	}()
+
+	return changes, nil
+}
+
+func (s *MemoryStore) notify(key string) {
+	for changes, prefix := range s.watchers {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		// Pending notifications coalesce; the reader reloads everything.
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// FileStore is a KVStore backed by a JSON object of string values, such as
+// {"app/server/addr": ":8080"}. The file is read on every call, so edits are
+// visible to the next Load. A missing file is an empty store.
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileStore returns a FileStore for path.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (s *FileStore) Get(_ context.Context, key string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	values, err := s.read()
+	if err != nil {
+		return "", false, err
+	}
+	value, ok := values[key]
+	return value, ok, nil
+}
+
+func (s *FileStore) List(_ context.Context, prefix string) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	values, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return listKVPrefix(values, prefix), nil
+}
+
+// Set stores value under key, rewriting the file atomically.
+func (s *FileStore) Set(key, value string) error {
+	return s.update(func(values map[string]string) { values[key] = value })
+}
+
+// Delete removes key, rewriting the file atomically.
+func (s *FileStore) Delete(key string) error {
+	return s.update(func(values map[string]string) { delete(values, key) })
+}
+
+func (s *FileStore) update(change func(map[string]string)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	values, err := s.read()
+	if err != nil {
+		return err
+	}
+	change(values)
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(values); err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, buf.Bytes(), 0o600)
+}
+
+func (s *FileStore) read() (map[string]string, error) {
+	content, err := os.ReadFile(s.path) //nolint:gosec // path is provided by the caller
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("read %s: %w", s.path, err)
+	}
+	values := map[string]string{}
+	if len(bytes.TrimSpace(content)) == 0 {
+		return values, nil
+	}
+	if err := json.Unmarshal(content, &values); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", s.path, err)
+	}
+	return values, nil
+}
+
+func listKVPrefix(values map[string]string, prefix string) map[string]string {
+	out := make(map[string]string)
+	for key, value := range values {
+		if strings.HasPrefix(key, prefix) {
+			out[key] = value
+		}
+	}
+	return out
+}
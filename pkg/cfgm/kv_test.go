@@ -0,0 +1,144 @@
+package cfgm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKVLoadsKeysUnderPrefix(t *testing.T) {
+	store := NewMemoryStore(map[string]string{
+		"app/server/addr":           ":9090",
+		"app/server/workers":        "8",
+		"app/server/tags":           `["a","b"]`,
+		"app/server/redis/password": "pw",
+		"app/":                      "ignored",
+		"application/server/addr":   ":1",
+		"other/server/addr":         ":2",
+	})
+
+	cfg, report, err := New(bindingDefaults(), WithoutDefaultPaths()).LoadReport(t.Context(), KV(store, "/app/"))
+	require.NoError(t, err)
+	assert.Equal(t, ":9090", cfg.Server.Addr)
+	assert.Equal(t, 8, cfg.Server.Workers)
+	assert.Equal(t, []string{"a", "b"}, cfg.Server.Tags)
+	assert.Equal(t, "pw", cfg.Server.Redis.Password)
+	assert.Equal(t, []SourceReport{{
+		Name: "kv:app/",
+		Keys: []string{"server.addr", "server.redis.password", "server.tags", "server.workers"},
+	}}, report.Sources)
+}
+
+func TestKVRejectsUnknownAndInvalidKeys(t *testing.T) {
+	manager := New(bindingDefaults(), WithoutDefaultPaths())
+
+	_, err := manager.Load(t.Context(), KV(NewMemoryStore(map[string]string{"app/server/typo": "x"}), "app"))
+	require.ErrorContains(t, err, "kv:app/")
+	require.ErrorContains(t, err, "server.typo")
+
+	_, err = manager.Load(t.Context(), KV(NewMemoryStore(map[string]string{"app/server/tags": "a,b"}), "app"))
+	require.ErrorContains(t, err, "app/server/tags")
+	require.ErrorContains(t, err, "JSON")
+
+	assert.Panics(t, func() { KV(nil, "app") })
+}
+
+func TestKVRejectsConflictingKeys(t *testing.T) {
+	manager := New(bindingDefaults(), WithoutDefaultPaths())
+
+	_, err := manager.Load(t.Context(), KV(NewMemoryStore(map[string]string{
+		"app/server":      "x",
+		"app/server/addr": ":9090",
+	}), "app"))
+	require.ErrorContains(t, err, "app/server/addr: conflicts with app/server")
+
+	_, err = manager.Load(t.Context(), KV(NewMemoryStore(map[string]string{
+		"app/server/addr":  ":9090",
+		"app/server/addr/": ":9091",
+	}), "app"))
+	require.ErrorContains(t, err, "app/server/addr/: conflicts with app/server/addr")
+}
+
+type failingKVStore struct{}
+
+func (failingKVStore) Get(context.Context, string) (string, bool, error) {
+	return "", false, errors.New("unavailable")
+}
+
+func (failingKVStore) List(context.Context, string) (map[string]string, error) {
+	return nil, errors.New("unavailable")
+}
+
+func TestKVStoreErrors(t *testing.T) {
+	_, err := New(bindingDefaults(), WithoutDefaultPaths()).Load(t.Context(), KV(failingKVStore{}, "app"))
+	require.ErrorContains(t, err, "kv:app/: unavailable")
+}
+
+func TestMemoryStoreWatch(t *testing.T) {
+	var store MemoryStore
+	ctx, cancel := context.WithCancel(t.Context())
+	changes, err := store.Watch(ctx, "app/")
+	require.NoError(t, err)
+
+	store.Set("other/key", "x")
+	store.Set("app/server/addr", ":1")
+	store.Set("app/server/addr", ":2")
+	select {
+	case <-changes:
+	case <-time.After(time.Second):
+		t.Fatal("expected change notification")
+	}
+	select {
+	case <-changes:
+		t.Fatal("notifications should coalesce")
+	default:
+	}
+
+	value, ok, err := store.Get(t.Context(), "app/server/addr")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, ":2", value)
+
+	store.Delete("app/server/addr")
+	<-changes
+	cancel()
+	for range changes {
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kv.json")
+	store := NewFileStore(path)
+
+	values, err := store.List(t.Context(), "app/")
+	require.NoError(t, err)
+	assert.Empty(t, values)
+
+	require.NoError(t, store.Set("app/server/addr", ":7000"))
+	require.NoError(t, store.Set("app/server/workers", "3"))
+	require.NoError(t, store.Set("tmp", "x"))
+	require.NoError(t, store.Delete("tmp"))
+
+	value, ok, err := store.Get(t.Context(), "app/server/addr")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, ":7000", value)
+	_, ok, err = store.Get(t.Context(), "tmp")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	cfg, err := New(bindingDefaults(), WithoutDefaultPaths()).Load(t.Context(), KV(store, "app"))
+	require.NoError(t, err)
+	assert.Equal(t, ":7000", cfg.Server.Addr)
+	assert.Equal(t, 3, cfg.Server.Workers)
+
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o600))
+	_, err = store.List(t.Context(), "")
+	require.ErrorContains(t, err, "parse "+path)
+}
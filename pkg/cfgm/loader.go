@@ -2,10 +2,13 @@ package cfgm
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
-	"sort"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/lwmacct/251207-go-pkg-cfgm/pkg/templexp"
 )
@@ -18,22 +21,173 @@ type Source interface {
 type SourceReport struct {
 	Name string
 	Keys []string
+	// Files lists the files read by sources that map several files onto
+	// one layer, such as SecretsDir.
+	Files []string
 }
 
 type Report struct {
-	Sources []SourceReport
+	// Profiles lists the active profiles in the order they were applied.
+	Profiles []string
+	Sources  []SourceReport
 }
 
-func expandTemplateValues(value any, path string, lookup templexp.LookupFunc) (any, error) {
+// layeredSource is implemented by sources that read several documents, such
+// as Dir. The loader validates, merges, and reports each layer separately so
+// errors and Report entries name the document that produced them.
+type layeredSource interface {
+	Source
+	loadLayers(ctx context.Context, schema Schema) ([]sourceLayer, error)
+}
+
+type sourceLayer struct {
+	name   string
+	data   map[string]any
+	files  []string
+	origin valueOrigin
+}
+
+func loadSourceLayers(ctx context.Context, source Source, schema Schema) ([]sourceLayer, error) {
+	if layered, ok := source.(layeredSource); ok {
+		return layered.loadLayers(ctx, schema)
+	}
+	data, err := source.Load(ctx, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return []sourceLayer{{name: source.Name(), data: data}}, nil
+}
+
+// mergeLayers implements Source.Load for layered sources used outside a
+// Manager.
+func mergeLayers(layers []sourceLayer, err error) (map[string]any, error) {
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]any{}
+	for _, layer := range layers {
+		mergeMaps(out, layer.data)
+	}
+
+	return out, nil
+}
+
+// referenceNamespace is the template namespace resolving other config keys,
+// as in ${cfg:server.host}.
+const referenceNamespace = "cfg"
+
+type expandState uint8
+
+const (
+	expandPending expandState = iota
+	expandRunning
+	expandDone
+)
+
+// templateCache keeps the templates compiled for one Manager, keyed by config
+// path, so reloads only parse strings that changed. A nil cache compiles every
+// template.
+type templateCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedTemplate
+}
+
+type cachedTemplate struct {
+	text     string
+	template *templexp.Template
+}
+
+func (c *templateCache) lookup(path, text string) *templexp.Template {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.entries[path]; ok && entry.text == text {
+		return entry.template
+	}
+	return nil
+}
+
+// replace keeps only the templates of the latest load, dropping paths whose
+// values no longer contain templates.
+func (c *templateCache) replace(entries map[string]cachedTemplate) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = entries
+}
+
+// templateExpander expands the string values of one effective config in
+// place. ${cfg:path} references expand their target first, so chains resolve
+// in any order and cycles are reported with the keys involved. A reference
+// to a missing key is an error unless its operator handles unset values.
+type templateExpander struct {
+	root     map[string]any
+	lookup   templexp.LookupFunc
+	opts     []templexp.Option
+	cache    *templateCache
+	origins  *valueOrigins
+	validate referenceValidator
+	compiled map[string]cachedTemplate
+	states   map[string]expandState
+	stack    []string
+}
+
+// referenceValidator checks a value substituted for a whole ${cfg:path}
+// template against the field at the config path it is stored at.
+type referenceValidator func(path string, value any) error
+
+func expandTemplateValues(
+	root map[string]any, lookup templexp.LookupFunc, opts []templexp.Option, cache *templateCache, origins *valueOrigins,
+	validate referenceValidator,
+) error {
+	e := &templateExpander{
+		root: root, lookup: lookup, cache: cache, origins: origins, validate: validate,
+		compiled: make(map[string]cachedTemplate), states: make(map[string]expandState),
+	}
+	e.opts = append(slices.Clip(opts),
+		templexp.WithResolver(referenceNamespace, e.resolveReference), templexp.WithStrictNamespace(referenceNamespace))
+	if _, err := e.expand(root, ""); err != nil {
+		return err
+	}
+	cache.replace(e.compiled)
+	return nil
+}
+
+// compile returns the template for the string at path, reusing the cached
+// template when the text is unchanged since the previous load.
+func (e *templateExpander) compile(path, text string) (*templexp.Template, error) {
+	tmpl := e.cache.lookup(path, text)
+	if tmpl == nil {
+		var err error
+		if tmpl, err = templexp.Parse(text); err != nil {
+			return nil, err
+		}
+	}
+	e.compiled[path] = cachedTemplate{text: text, template: tmpl}
+	return tmpl, nil
+}
+
+func (e *templateExpander) expand(value any, path string) (any, error) {
+	e.states[path] = expandRunning
+	e.stack = append(e.stack, path)
+	defer func() {
+		e.states[path] = expandDone
+		e.stack = e.stack[:len(e.stack)-1]
+	}()
+
 	switch typed := value.(type) {
 	case map[string]any:
-		keys := make([]string, 0, len(typed))
-		for key := range typed {
-			keys = append(keys, key)
-		}
-		sort.Strings(keys)
-		for _, key := range keys {
-			expanded, err := expandTemplateValues(typed[key], templateMapPath(path, key), lookup)
+		for _, key := range slices.Sorted(maps.Keys(typed)) {
+			childPath := templateMapPath(path, key)
+			if e.states[childPath] == expandDone {
+				continue
+			}
+			expanded, err := e.expand(typed[key], childPath)
 			if err != nil {
 				return nil, err
 			}
@@ -42,7 +196,7 @@ func expandTemplateValues(value any, path string, lookup templexp.LookupFunc) (a
 		return typed, nil
 	case []any:
 		for index, item := range typed {
-			expanded, err := expandTemplateValues(item, fmt.Sprintf("%s[%d]", path, index), lookup)
+			expanded, err := e.expand(item, fmt.Sprintf("%s[%d]", path, index))
 			if err != nil {
 				return nil, err
 			}
@@ -53,9 +207,28 @@ func expandTemplateValues(value any, path string, lookup templexp.LookupFunc) (a
 		if !containsTemplateMarker(typed) {
 			return typed, nil
 		}
-		expanded, err := templexp.Expand(typed, lookup)
+		if target, ok := wholeReference(typed); ok {
+			// A template that is only a reference keeps the target's type.
+			if referenced, found, err := e.reference(target); err != nil || found {
+				if err != nil {
+					return nil, e.wrapError(path, typed, err)
+				}
+				value := cloneConfigValue(referenced)
+				if e.validate != nil {
+					if err := e.validate(path, value); err != nil {
+						return nil, e.wrapError(path, typed, err)
+					}
+				}
+				return value, nil
+			}
+		}
+		tmpl, err := e.compile(path, typed)
+		if err != nil {
+			return nil, e.wrapError(path, typed, err)
+		}
+		expanded, err := tmpl.Execute(e.lookup, e.opts...)
 		if err != nil {
-			return nil, fmt.Errorf("expand template at %s: %w", path, err)
+			return nil, e.wrapError(path, typed, err)
 		}
 		return expanded, nil
 	default:
@@ -63,6 +236,128 @@ func expandTemplateValues(value any, path string, lookup templexp.LookupFunc) (a
 	}
 }
 
+// templateValueError is the error of expanding the template at one config
+// path, prefixed with the file:line:col of the value when known.
+type templateValueError struct {
+	location string
+	path     string
+	err      error
+}
+
+func (e *templateValueError) Error() string {
+	message := fmt.Sprintf("expand template at %s: %v", templateMapPath("root", e.path), e.err)
+	if e.location == "" {
+		return message
+	}
+	return e.location + ": " + message
+}
+
+func (e *templateValueError) Unwrap() error { return e.err }
+
+// wrapError names the config path of the template text that failed and,
+// when the value came from a file, its file:line:col. Errors of referenced
+// values already name their own location, so they are not located again.
+func (e *templateExpander) wrapError(path, text string, err error) error {
+	wrapped := &templateValueError{path: path, err: err}
+	var referenced *templateValueError
+	if !errors.As(err, &referenced) {
+		wrapped.location = e.origins.locate(path, text, err)
+	}
+	return wrapped
+}
+
+// reference returns the expanded value at the dotted config path target.
+func (e *templateExpander) reference(target string) (any, bool, error) {
+	switch e.states[target] {
+	case expandRunning:
+		cycle := append(slices.Clone(e.stack[slices.Index(e.stack, target):]), target)
+		return nil, false, fmt.Errorf("reference cycle: %s", strings.Join(cycle, " -> "))
+	case expandDone:
+		value, found := lookupConfigPath(e.root, target)
+		return value, found, nil
+	}
+
+	parent := e.root
+	keys := strings.Split(target, ".")
+	for _, key := range keys[:len(keys)-1] {
+		child, ok := parent[key].(map[string]any)
+		if !ok {
+			return nil, false, nil
+		}
+		parent = child
+	}
+	value, found := parent[keys[len(keys)-1]]
+	if !found {
+		return nil, false, nil
+	}
+	expanded, err := e.expand(value, target)
+	if err != nil {
+		return nil, false, err
+	}
+	parent[keys[len(keys)-1]] = expanded
+	return expanded, true, nil
+}
+
+func (e *templateExpander) resolveReference(target string) (string, bool, error) {
+	value, found, err := e.reference(target)
+	if err != nil || !found {
+		return "", false, err
+	}
+	switch value.(type) {
+	case map[string]any, []any:
+		return "", false, fmt.Errorf("%s is not a scalar value", target)
+	case nil:
+		return "", true, nil
+	}
+	return fmt.Sprint(value), true, nil
+}
+
+// wholeReference reports whether text is exactly one ${cfg:path} reference.
+func wholeReference(text string) (string, bool) {
+	target, ok := strings.CutPrefix(text, "${"+referenceNamespace+":")
+	if !ok {
+		return "", false
+	}
+	target, ok = strings.CutSuffix(target, "}")
+	if !ok || target == "" || strings.ContainsAny(target, "${}") || strings.Contains(target, ":") {
+		return "", false
+	}
+	return target, true
+}
+
+func lookupConfigPath(root map[string]any, path string) (any, bool) {
+	var current any = root
+	for key := range strings.SplitSeq(path, ".") {
+		object, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func cloneConfigValue(value any) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(typed))
+		for key, child := range typed {
+			out[key] = cloneConfigValue(child)
+		}
+		return out
+	case []any:
+		out := make([]any, len(typed))
+		for index, item := range typed {
+			out[index] = cloneConfigValue(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
 func environmentSnapshot() templexp.LookupFunc {
 	values := make(map[string]string)
 	for _, entry := range os.Environ() {
@@ -79,6 +374,38 @@ func environmentSnapshot() templexp.LookupFunc {
 	}
 }
 
+// templateOptions registers the built-in file, env, and base64 resolvers and
+// then the resolvers added with WithTemplateResolver, which may replace them.
+// The env resolver reads the environment snapshot of the load.
+func templateOptions(lookup templexp.LookupFunc, resolvers map[string]templexp.Resolver) []templexp.Option {
+	opts := []templexp.Option{
+		templexp.WithResolver("file", templexp.FileResolver()),
+		templexp.WithResolver("env", templexp.EnvResolver(lookup)),
+		templexp.WithResolver("base64", templexp.Base64Resolver()),
+	}
+	for namespace, resolver := range resolvers {
+		opts = append(opts, templexp.WithResolver(namespace, resolver))
+	}
+	return opts
+}
+
+// withTemplateVariables resolves names from lookup first and falls back to
+// variables contributed by sources such as DotEnv.
+func withTemplateVariables(lookup templexp.LookupFunc, variables map[string]string) templexp.LookupFunc {
+	if len(variables) == 0 {
+		return lookup
+	}
+
+	return func(name string) (string, bool) {
+		if value, found := lookup(name); found {
+			return value, true
+		}
+		value, found := variables[name]
+
+		return value, found
+	}
+}
+
 func templateMapPath(parent, key string) string {
 	if parent == "" {
 		return key
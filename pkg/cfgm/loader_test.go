@@ -1,6 +1,8 @@
 package cfgm
 
 import (
+	"fmt"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -39,3 +41,60 @@ func TestLoadReportDeduplicatesCompositePaths(t *testing.T) {
 	require.Len(t, report.Sources, 1)
 	assert.Equal(t, []string{"items.name"}, report.Sources[0].Keys)
 }
+
+func TestManagerCachesTemplatesAcrossLoads(t *testing.T) {
+	type Config struct {
+		Name  string   `json:"name"`
+		Hosts []string `json:"hosts"`
+	}
+	t.Setenv("CFG_NAME", "cached")
+	path := writeTempConfig(t, "name: ${CFG_NAME}\nhosts: [\"${CFG_NAME}-a\", plain]\n")
+	manager := New(Config{}, WithoutDefaultPaths())
+
+	cfg, err := manager.Load(t.Context(), File(path))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cached-a", "plain"}, cfg.Hosts)
+	first := manager.templates.entries
+	require.Len(t, first, 2)
+	assert.Contains(t, first, "hosts[0]")
+
+	t.Setenv("CFG_NAME", "reloaded")
+	cfg, err = manager.Load(t.Context(), File(path))
+	require.NoError(t, err)
+	assert.Equal(t, "reloaded", cfg.Name)
+	assert.Same(t, first["name"].template, manager.templates.entries["name"].template)
+
+	require.NoError(t, os.WriteFile(path, []byte("name: x-${CFG_NAME}\n"), 0o600))
+	cfg, err = manager.Load(t.Context(), File(path))
+	require.NoError(t, err)
+	assert.Equal(t, "x-reloaded", cfg.Name)
+	require.Len(t, manager.templates.entries, 1)
+	assert.NotSame(t, first["name"].template, manager.templates.entries["name"].template)
+}
+
+func benchmarkTemplateConfig() map[string]any {
+	config := make(map[string]any, 200)
+	for index := range 200 {
+		config[fmt.Sprintf("key%d", index)] = fmt.Sprintf("${HOST:-localhost}:${PORT_%d:-80}/path", index)
+	}
+	return config
+}
+
+func BenchmarkExpandTemplateValues(b *testing.B) {
+	lookup := func(string) (string, bool) { return "", false }
+	b.Run("uncached", func(b *testing.B) {
+		for b.Loop() {
+			if err := expandTemplateValues(benchmarkTemplateConfig(), lookup, nil, nil, nil, nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		cache := &templateCache{}
+		for b.Loop() {
+			if err := expandTemplateValues(benchmarkTemplateConfig(), lookup, nil, cache, nil, nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
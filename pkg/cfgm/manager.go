@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log/slog"
 	"maps"
 	"reflect"
@@ -14,6 +15,7 @@ import (
 	"time"
 
 	"github.com/go-viper/mapstructure/v2"
+	"github.com/lwmacct/251207-go-pkg-cfgm/pkg/templexp"
 	"github.com/urfave/cli/v3"
 )
 
@@ -37,6 +39,20 @@ type managerOptions struct {
 	logger           *slog.Logger
 	aliases          map[string][]string
 	noCLI            map[string]bool
+	formats          []Format
+	includes         bool
+	envFiles         bool
+	fsys             fs.FS
+	profiles         bool
+	profileEnv       string
+	secretsDirEnv    string
+	searchPaths      *SearchPaths
+	keyProvider      KeyProvider
+	resolvers        map[string]templexp.Resolver
+	strictTemplates  bool
+	allowUnset       []string
+	baselineName     string
+	baselineData     []byte
 }
 
 func AppName(name string) Option {
@@ -57,6 +73,50 @@ func WithoutTemplateExpansion() Option {
 	})
 }
 
+// WithIncludes lets config files pull in other files through a top-level
+// include key holding one path or a list of paths. Relative paths resolve
+// against the including file. Included files merge in order before the
+// including file's own keys and are reported as separate sources. Cycles and
+// nesting deeper than 16 files are errors.
+func WithIncludes() Option {
+	return managerOptionFunc(func(options *managerOptions) {
+		options.includes = true
+	})
+}
+
+// WithTemplateResolver makes ${namespace:argument} templates in config values
+// and file paths resolve through resolver. The file, env, and base64
+// namespaces are built in; registering one of them replaces it.
+func WithTemplateResolver(namespace string, resolver templexp.Resolver) Option {
+	templexp.WithResolver(namespace, resolver) // validates namespace and resolver
+	return managerOptionFunc(func(options *managerOptions) {
+		if options.resolvers == nil {
+			options.resolvers = make(map[string]templexp.Resolver)
+		}
+		options.resolvers[namespace] = resolver
+	})
+}
+
+// WithStrictTemplates makes a template reference to an unset variable, such
+// as a bare ${VAR}, fail the load instead of expanding to an empty string.
+// Variables in allowed may still be unset. Operators that handle unset
+// variables, such as ${VAR:-word}, are unaffected. This applies to config
+// values and file paths.
+func WithStrictTemplates(allowed ...string) Option {
+	return managerOptionFunc(func(options *managerOptions) {
+		options.strictTemplates = true
+		options.allowUnset = append(options.allowUnset, allowed...)
+	})
+}
+
+// WithEnvFiles makes the environment source used by Manager.Action honor
+// <NAME>_FILE variables. See EnvFiles.
+func WithEnvFiles() Option {
+	return managerOptionFunc(func(options *managerOptions) {
+		options.envFiles = true
+	})
+}
+
 func AllowUnknownKeys() Option {
 	return managerOptionFunc(func(options *managerOptions) {
 		options.allowUnknownKeys = true
@@ -150,6 +210,19 @@ type Manager[T any] struct {
 	logger            *slog.Logger
 	aliases           map[string][]string
 	noCLI             map[string]bool
+	formats           *formatSet
+	includes          bool
+	envFiles          bool
+	fsys              fs.FS
+	profiles          bool
+	profileEnv        string
+	secretsDirEnv     string
+	searchPaths       *SearchPaths
+	keyProvider       KeyProvider
+	resolvers         map[string]templexp.Resolver
+	strictTemplates   bool
+	allowUnset        []string
+	templates         *templateCache
 	bindings          map[string]*commandBinding[T]
 	commands          map[*cli.Command]*commandBinding[T]
 	configured        bool
@@ -174,10 +247,32 @@ func New[T any](defaults T, opts ...Option) *Manager[T] {
 		logger:            options.logger,
 		aliases:           mapsCloneSlices(options.aliases),
 		noCLI:             mapsClone(options.noCLI),
+		formats:           &formatSet{local: options.formats},
+		includes:          options.includes,
+		envFiles:          options.envFiles,
+		fsys:              options.fsys,
+		profiles:          options.profiles,
+		profileEnv:        options.profileEnv,
+		secretsDirEnv:     options.secretsDirEnv,
+		searchPaths:       options.searchPaths,
+		keyProvider:       options.keyProvider,
+		resolvers:         mapsClone(options.resolvers),
+		strictTemplates:   options.strictTemplates,
+		allowUnset:        slices.Clone(options.allowUnset),
+		templates:         &templateCache{},
 		bindings:          make(map[string]*commandBinding[T]),
 		commands:          make(map[*cli.Command]*commandBinding[T]),
 	}
 	manager.validateCLIOptions()
+	if manager.includes && manager.schema.paths[includeKey] != nil {
+		panic(fmt.Sprintf("cfgm: WithIncludes conflicts with config field %q", includeKey))
+	}
+	if manager.profiles && manager.schema.paths[profilesKey] != nil {
+		panic(fmt.Sprintf("cfgm: WithProfiles conflicts with config field %q", profilesKey))
+	}
+	if options.baselineData != nil {
+		manager.applyBaseline(options.baselineName, options.baselineData)
+	}
 	return manager
 }
 
@@ -199,13 +294,27 @@ func (m *Manager[T]) MustLoad(ctx context.Context, sources ...Source) *T {
 // LoadReport loads config and reports the keys contributed by each source.
 func (m *Manager[T]) LoadReport(ctx context.Context, sources ...Source) (*T, *Report, error) {
 	loader := m.loader()
+	profiles, err := m.envProfiles()
+	if err != nil {
+		return nil, nil, err
+	}
+	loader.profiles = profiles
 	if m.defaultPaths {
-		loader.sources = append(loader.sources, Files(DefaultPaths(m.appName), Optional()))
+		loader.sources = append(loader.sources, m.searchSource(m.appName))
 	}
 	loader.sources = append(loader.sources, sources...)
 	return loader.load(ctx)
 }
 
+// searchSource returns the source for WithSearchPaths, or the default
+// locations of appName.
+func (m *Manager[T]) searchSource(appName string) Source {
+	if m.searchPaths != nil {
+		return m.searchPaths.Source()
+	}
+	return NewSearchPaths(appName).Source()
+}
+
 func (m *Manager[T]) loader() *configLoader[T] {
 	return &configLoader[T]{
 		defaults:          m.defaults,
@@ -214,6 +323,15 @@ func (m *Manager[T]) loader() *configLoader[T] {
 		expandTemplates:   m.expandTemplates,
 		strictUnknownKeys: m.strictUnknownKeys,
 		codecs:            m.codecs,
+		formats:           m.formats,
+		includes:          m.includes,
+		fsys:              m.fsys,
+		profileSections:   m.profiles,
+		keyProvider:       m.keyProvider,
+		resolvers:         m.resolvers,
+		strictTemplates:   m.strictTemplates,
+		allowUnset:        m.allowUnset,
+		templates:         m.templates,
 	}
 }
 
@@ -255,7 +373,7 @@ func (m *Manager[T]) validateCLIOptions() {
 		}
 		seen := make(map[string]bool, len(aliases))
 		for _, alias := range aliases {
-			if isReservedFlagName(alias) {
+			if m.isReservedFlagName(alias) {
 				panic(fmt.Errorf("cfgm: alias --%s is reserved", alias))
 			}
 			if seen[alias] {
@@ -283,8 +401,11 @@ func (m *Manager[T]) newCommandBinding(commandPath string, rootOnly bool) (*comm
 			continue
 		}
 		name := bindingFlagName(field.path, commandPath)
-		if isReservedFlagName(name) {
-			return nil, fmt.Errorf("cfgm: generated CLI flag --%s is reserved", name)
+		if m.isReservedFlagName(name) {
+			return nil, fmt.Errorf(
+				"cfgm: config field %s conflicts with reserved CLI flag --%s; rename it or hide it with HideCLI",
+				field.path, name,
+			)
 		}
 		field.aliases = append([]string(nil), m.aliases[field.path]...)
 		for _, flagName := range append([]string{name}, field.aliases...) {
@@ -299,8 +420,13 @@ func (m *Manager[T]) newCommandBinding(commandPath string, rootOnly bool) (*comm
 	return &commandBinding[T]{manager: m, commandPath: commandPath, fields: fields}, nil
 }
 
+func (m *Manager[T]) isReservedFlagName(name string) bool {
+	return isReservedFlagName(name) || m.profiles && name == profileFlagName
+}
+
 func isReservedFlagName(name string) bool {
-	return name == configFlagName || name == envPrefixFlagName ||
+	return name == configFlagName || name == envPrefixFlagName || name == secretsDirFlagName ||
+		name == setFlagName ||
 		name == "c" || name == "e" || name == "help" || name == "h"
 }
 
@@ -340,7 +466,11 @@ func (m *Manager[T]) Configure(root *cli.Command) error {
 	if err != nil {
 		return err
 	}
-	mergedRootFlags, err := mergeCLIFlags(root.Flags, append(rootFlags(), rootConfigFlags...))
+	managedRootFlags := rootFlags(m.secretsDirEnv)
+	if m.profiles {
+		managedRootFlags = append(managedRootFlags, profileFlag(m.profileEnv))
+	}
+	mergedRootFlags, err := mergeCLIFlags(root.Flags, append(managedRootFlags, rootConfigFlags...))
 	if err != nil {
 		return fmt.Errorf("cfgm: configure root command: %w", err)
 	}
@@ -475,25 +605,42 @@ func (m *Manager[T]) loadCommandReport(ctx context.Context, cmd *cli.Command) (*
 		return nil, nil, fmt.Errorf("cfgm: command path %q was not configured by this manager", commandPath)
 	}
 	loader := m.loader()
+	profiles, err := m.commandProfiles(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+	loader.profiles = profiles
 
 	appName := m.appName
 	if appName == "" {
 		appName = commandRootName(cmd)
 	}
 	if m.defaultPaths {
-		loader.sources = append(loader.sources, Files(DefaultPaths(appName), Optional()))
+		loader.sources = append(loader.sources, m.searchSource(appName))
 	}
-	if configPath := commandConfigPath(cmd); configPath != "" {
+	if configPath := commandConfigPath(cmd); configPath == stdinConfigPath {
+		loader.sources = append(loader.sources, Reader("stdin", "", commandStdin(cmd)))
+	} else if configPath != "" {
 		loader.sources = append(loader.sources, File(configPath))
 	}
+	var envOptions []EnvOption
+	if m.envFiles {
+		envOptions = append(envOptions, EnvFiles())
+	}
 	if prefix, ok := commandEnvPrefix(cmd); ok {
 		if prefix != "" {
-			loader.sources = append(loader.sources, Env(prefix))
+			loader.sources = append(loader.sources, Env(prefix, envOptions...))
 		}
 	} else if appName != "" {
-		loader.sources = append(loader.sources, Env(strings.ToUpper(strings.ReplaceAll(appName, "-", "_"))+"_"))
+		loader.sources = append(loader.sources, Env(strings.ToUpper(strings.ReplaceAll(appName, "-", "_"))+"_", envOptions...))
+	}
+	if secretsDir := commandSecretsDir(cmd); secretsDir != "" {
+		loader.sources = append(loader.sources, SecretsDir(secretsDir))
 	}
 	loader.sources = append(loader.sources, &bindingCLISource[T]{binding: binding, cmd: cmd})
+	if assignments := commandAssignments(cmd); len(assignments) > 0 {
+		loader.sources = append(loader.sources, Set(assignments...))
+	}
 	return loader.load(ctx)
 }
 
@@ -583,7 +730,7 @@ func (b *commandBinding[T]) newStructSliceFlag(bound boundField, defaultValue re
 		Aliases:     append([]string(nil), bound.field.aliases...),
 		Usage:       bound.field.desc,
 		DefaultText: defaultText,
-		Value:       newStructSliceValue(bound.field.typ, b.manager.codecs),
+		Value:       newStructSliceValue(bound.field.typ, b.manager.codecs, b.manager.expandTemplates),
 	}
 }
 
@@ -890,9 +1037,10 @@ func (m *schemaModel) validateData(
 	data map[string]any,
 	codecs map[reflect.Type]valueCodec,
 	allowUnknownKeys bool,
+	references bool,
 ) error {
 	var unknown []string
-	if err := validateConfigValue(data, m.rootType, "", false, codecs, &unknown); err != nil {
+	if err := validateConfigValue(data, m.rootType, "", false, codecs, references, &unknown); err != nil {
 		return err
 	}
 	if allowUnknownKeys || len(unknown) == 0 {
@@ -908,13 +1056,14 @@ func validateConfigValue(
 	path string,
 	nullable bool,
 	codecs map[reflect.Type]valueCodec,
+	references bool,
 	unknown *[]string,
 ) error {
 	if typ.Kind() == reflect.Pointer {
 		if value == nil {
 			return nil
 		}
-		return validateConfigValue(value, typ.Elem(), path, true, codecs, unknown)
+		return validateConfigValue(value, typ.Elem(), path, true, codecs, references, unknown)
 	}
 	if value == nil {
 		if nullable || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Map {
@@ -922,6 +1071,12 @@ func validateConfigValue(
 		}
 		return fmt.Errorf("config key %q cannot be null", path)
 	}
+	if text, ok := value.(string); ok && references {
+		if _, reference := wholeReference(text); reference {
+			// ${cfg:path} takes the type of its target during expansion.
+			return nil
+		}
+	}
 	if _, ok := codecs[typ]; ok {
 		if _, stringValue := value.(string); !stringValue {
 			return fmt.Errorf("config key %q must be a string for codec %s", path, typ)
@@ -950,7 +1105,7 @@ func validateConfigValue(
 				*unknown = append(*unknown, childPath)
 				continue
 			}
-			if err := validateConfigValue(child, fieldType, childPath, false, codecs, unknown); err != nil {
+			if err := validateConfigValue(child, fieldType, childPath, false, codecs, references, unknown); err != nil {
 				return err
 			}
 		}
@@ -960,7 +1115,7 @@ func validateConfigValue(
 			return fmt.Errorf("config key %q must be an array", path)
 		}
 		for _, item := range items {
-			if err := validateConfigValue(item, typ.Elem(), path, false, codecs, unknown); err != nil {
+			if err := validateConfigValue(item, typ.Elem(), path, false, codecs, references, unknown); err != nil {
 				return err
 			}
 		}
@@ -970,7 +1125,7 @@ func validateConfigValue(
 			return fmt.Errorf("config key %q must be an object", path)
 		}
 		for key, child := range object {
-			if err := validateConfigValue(child, typ.Elem(), joinSchemaPath(path, key), false, codecs, unknown); err != nil {
+			if err := validateConfigValue(child, typ.Elem(), joinSchemaPath(path, key), false, codecs, references, unknown); err != nil {
 				return err
 			}
 		}
@@ -978,6 +1133,117 @@ func validateConfigValue(
 	return nil
 }
 
+// validateReference checks value, substituted for a whole ${cfg:path}
+// template at path, as validateData checks file values. Its scalars must also
+// keep their kind, so weak decoding does not turn a number into a bool or a
+// bool into a number.
+func (m *schemaModel) validateReference(
+	path string,
+	value any,
+	codecs map[reflect.Type]valueCodec,
+	allowUnknownKeys bool,
+) error {
+	typ, ok := m.typeAt(path)
+	if !ok {
+		return nil
+	}
+	var unknown []string
+	if err := validateConfigValue(value, typ, path, false, codecs, false, &unknown); err != nil {
+		return err
+	}
+	if !allowUnknownKeys && len(unknown) > 0 {
+		slices.Sort(unknown)
+		return fmt.Errorf("unknown config keys:\n  - %s", strings.Join(unknown, "\n  - "))
+	}
+	return validateScalarKinds(value, typ, path, codecs)
+}
+
+// typeAt returns the type of the value at a config path such as a.b[1].c.
+func (m *schemaModel) typeAt(path string) (reflect.Type, bool) {
+	typ := m.rootType
+	for _, segment := range configPathSegments(path) {
+		for typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+		if _, ok := m.codecs[typ]; ok {
+			return nil, false
+		}
+		if typ == durationType || typ == timeType {
+			return nil, false
+		}
+		if typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array {
+			typ = typ.Elem()
+			continue
+		}
+		child, ok := childType(typ, segment)
+		if !ok {
+			return nil, false
+		}
+		typ = child
+	}
+	return typ, true
+}
+
+// validateScalarKinds rejects bools stored in numeric fields and numbers
+// stored in bool fields below value.
+func validateScalarKinds(value any, typ reflect.Type, path string, codecs map[reflect.Type]valueCodec) error {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if _, ok := codecs[typ]; ok || value == nil || typ == durationType || typ == timeType {
+		return nil
+	}
+	switch typed := value.(type) {
+	case map[string]any:
+		for key, child := range typed {
+			keyType, ok := childType(typ, key)
+			if !ok {
+				continue
+			}
+			if err := validateScalarKinds(child, keyType, joinSchemaPath(path, key), codecs); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range typed {
+			if err := validateScalarKinds(item, typ.Elem(), path, codecs); err != nil {
+				return err
+			}
+		}
+	case string:
+	case bool:
+		if isNumberKind(typ.Kind()) {
+			return fmt.Errorf("config key %q must be a number, got %v", path, typed)
+		}
+	default:
+		if typ.Kind() == reflect.Bool {
+			return fmt.Errorf("config key %q must be a bool, got %v", path, typed)
+		}
+	}
+	return nil
+}
+
+// childType returns the type of key in a struct or map type.
+func childType(typ reflect.Type, key string) (reflect.Type, bool) {
+	if typ.Kind() == reflect.Map {
+		return typ.Elem(), true
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, false
+	}
+	configured, _ := configFields(typ)
+	for _, field := range configured {
+		if configTagName(field.field) == key {
+			return field.field.Type, true
+		}
+	}
+	return nil, false
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
+
 func (m *schemaModel) hasPath(path string) bool {
 	if _, ok := m.paths[path]; ok {
 		return true
@@ -1006,14 +1272,15 @@ func isStructSlice(typ reflect.Type) bool {
 }
 
 type structSliceValue struct {
-	typ     reflect.Type
-	codecs  map[reflect.Type]valueCodec
-	items   []map[string]any
-	cleared bool
+	typ        reflect.Type
+	codecs     map[reflect.Type]valueCodec
+	references bool
+	items      []map[string]any
+	cleared    bool
 }
 
-func newStructSliceValue(typ reflect.Type, codecs map[reflect.Type]valueCodec) *structSliceValue {
-	return &structSliceValue{typ: typ, codecs: codecs}
+func newStructSliceValue(typ reflect.Type, codecs map[reflect.Type]valueCodec, references bool) *structSliceValue {
+	return &structSliceValue{typ: typ, codecs: codecs, references: references}
 }
 
 func (v *structSliceValue) Set(raw string) error {
@@ -1042,7 +1309,7 @@ func (v *structSliceValue) Set(raw string) error {
 	if err := ensureJSONEOF(decoder); err != nil {
 		return err
 	}
-	if err := validateJSONObject(item, targetType, "", v.codecs); err != nil {
+	if err := validateJSONObject(item, targetType, "", v.codecs, v.references); err != nil {
 		return err
 	}
 	v.items = append(v.items, item)
@@ -1081,6 +1348,16 @@ type configLoader[T any] struct {
 	expandTemplates   bool
 	strictUnknownKeys bool
 	codecs            map[reflect.Type]valueCodec
+	formats           *formatSet
+	includes          bool
+	fsys              fs.FS
+	profileSections   bool
+	profiles          []string
+	keyProvider       KeyProvider
+	resolvers         map[string]templexp.Resolver
+	strictTemplates   bool
+	allowUnset        []string
+	templates         *templateCache
 }
 
 func (l *configLoader[T]) load(ctx context.Context) (*T, *Report, error) {
@@ -1089,7 +1366,13 @@ func (l *configLoader[T]) load(ctx context.Context) (*T, *Report, error) {
 	}
 	configMap := structToMap(l.defaults)
 	lookup := environmentSnapshot()
-	report := &Report{}
+	templateVars := make(map[string]string)
+	resolverOptions := templateOptions(lookup, l.resolvers)
+	if l.strictTemplates {
+		resolverOptions = append(resolverOptions, templexp.WithStrict(l.allowUnset...))
+	}
+	report := &Report{Profiles: l.profiles}
+	origins := newValueOrigins()
 	for _, source := range l.sources {
 		if err := ctx.Err(); err != nil {
 			return nil, report, err
@@ -1097,24 +1380,48 @@ func (l *configLoader[T]) load(ctx context.Context) (*T, *Report, error) {
 		if source == nil {
 			continue
 		}
-		data, err := source.Load(ctx, Schema{model: l.schema, codecs: l.codecs, lookup: lookup})
+		layers, err := loadSourceLayers(ctx, source, Schema{
+			model: l.schema, codecs: l.codecs, lookup: lookup, formats: l.formats, templateVars: templateVars,
+			templateOptions: resolverOptions,
+			documents:       origins.documents,
+			includes:        l.includes, fsys: l.fsys, profileSections: l.profileSections, profiles: l.profiles,
+		})
 		if err != nil {
 			return nil, report, fmt.Errorf("%s: %w", source.Name(), err)
 		}
-		keys := flattenSchemaKeys(data)
-		slices.Sort(keys)
-		if err := l.schema.validateData(data, l.codecs, !l.strictUnknownKeys); err != nil {
-			return nil, report, fmt.Errorf("%s: %w", source.Name(), err)
+		if len(layers) == 0 {
+			report.Sources = append(report.Sources, SourceReport{Name: source.Name()})
+			l.logger.DebugContext(ctx, "Loaded config source", "source", source.Name(), "keys", []string(nil))
+		}
+		for _, layer := range layers {
+			keys := flattenSchemaKeys(layer.data)
+			if err := l.schema.validateData(layer.data, l.codecs, !l.strictUnknownKeys, l.expandTemplates); err != nil {
+				return nil, report, fmt.Errorf("%s: %w", layer.name, err)
+			}
+			if l.keyProvider != nil {
+				if err := checkEncryptedValues(layer.data, l.schema.rootType); err != nil {
+					return nil, report, fmt.Errorf("%s: %w", layer.name, err)
+				}
+			}
+			mergeMaps(configMap, layer.data)
+			origins.record(layer)
+			report.Sources = append(report.Sources, SourceReport{Name: layer.name, Keys: keys, Files: layer.files})
+			l.logger.DebugContext(ctx, "Loaded config source", "source", layer.name, "keys", keys)
 		}
-		mergeMaps(configMap, data)
-		report.Sources = append(report.Sources, SourceReport{Name: source.Name(), Keys: keys})
-		l.logger.DebugContext(ctx, "Loaded config source", "source", source.Name(), "keys", keys)
 	}
 	if l.expandTemplates {
-		if _, err := expandTemplateValues(configMap, "root", lookup); err != nil {
+		validate := func(path string, value any) error {
+			return l.schema.validateReference(path, value, l.codecs, !l.strictUnknownKeys)
+		}
+		if err := expandTemplateValues(
+			configMap, withTemplateVariables(lookup, templateVars), resolverOptions, l.templates, origins, validate,
+		); err != nil {
 			return nil, report, fmt.Errorf("expand template in effective config: %w", err)
 		}
 	}
+	if err := decryptConfigValues(ctx, configMap, l.schema.rootType, l.keyProvider); err != nil {
+		return nil, report, err
+	}
 	var config T
 	if err := decodeConfigMapWithCodecs(configMap, &config, l.codecs); err != nil {
 		return nil, report, fmt.Errorf("failed to unmarshal config: %w", err)
@@ -1142,8 +1449,10 @@ func decodeConfigMapWithCodecs(data map[string]any, out any, codecs map[reflect.
 		DecodeHook:       mapstructure.ComposeDecodeHookFunc(hooks...),
 		Result:           out,
 		WeaklyTypedInput: true,
-		TagName:          "cfgm,json",
-		SquashTagOption:  "inline",
+		// json names the field; cfgm is only read for the ",inline" tag of
+		// fields without a json tag, so cfgm:",secret" keeps the json name.
+		TagName:         "json,cfgm",
+		SquashTagOption: "inline",
 	})
 	if err != nil {
 		return err
@@ -1220,9 +1529,10 @@ func validateJSONObject(
 	typ reflect.Type,
 	prefix string,
 	codecs map[reflect.Type]valueCodec,
+	references bool,
 ) error {
 	var unknown []string
-	if err := validateConfigValue(item, typ, prefix, false, codecs, &unknown); err != nil {
+	if err := validateConfigValue(item, typ, prefix, false, codecs, references, &unknown); err != nil {
 		return err
 	}
 	if len(unknown) > 0 {
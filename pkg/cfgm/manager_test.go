@@ -73,6 +73,8 @@ func TestManagerGeneratesTypedFlags(t *testing.T) {
 	assert.Equal(t, []string{"c"}, configFlag.Aliases)
 	envPrefixFlag := requireFlagType[*cli.StringFlag](t, root.Flags, "env-prefix")
 	assert.Equal(t, []string{"e"}, envPrefixFlag.Aliases)
+	secretsDirFlag := requireFlagType[*cli.StringFlag](t, root.Flags, "secrets-dir")
+	assert.Empty(t, secretsDirFlag.Sources.EnvKeys())
 	assert.Nil(t, findFlag(root.Flags, "server.addr"))
 
 	flags := server.Flags
@@ -305,6 +307,72 @@ func TestManagerRejectsInvalidCompositeEnvironmentValues(t *testing.T) {
 	require.ErrorContains(t, err, "JSON")
 }
 
+func TestEnvFilesReadsFileVariants(t *testing.T) {
+	type Config struct {
+		Password string   `json:"password"`
+		Tags     []string `json:"tags"`
+		Cert     string   `json:"cert"`
+		CertFile string   `json:"cert-file"`
+	}
+	dir := writeConfigDir(t, map[string]string{"password": "s3cret\n", "tags": `["a","b"]`})
+	t.Setenv("APP_PASSWORD_FILE", dir+"/password")
+	t.Setenv("APP_TAGS_FILE", dir+"/tags")
+	t.Setenv("APP_CERT_FILE", "/direct/path.pem")
+
+	cfg, err := New(Config{}, WithoutDefaultPaths()).Load(t.Context(), Env("APP_", EnvFiles()))
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", cfg.Password)
+	assert.Equal(t, []string{"a", "b"}, cfg.Tags)
+	assert.Empty(t, cfg.Cert)
+	assert.Equal(t, "/direct/path.pem", cfg.CertFile)
+
+	cfg, err = New(Config{}, WithoutDefaultPaths()).Load(t.Context(), Env("APP_"))
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Password)
+}
+
+func TestEnvFilesErrors(t *testing.T) {
+	type Config struct {
+		Password string   `json:"password"`
+		Tags     []string `json:"tags"`
+	}
+	manager := New(Config{}, WithoutDefaultPaths())
+
+	t.Run("both set", func(t *testing.T) {
+		t.Setenv("APP_PASSWORD", "direct")
+		t.Setenv("APP_PASSWORD_FILE", "/run/secrets/password")
+		_, err := manager.Load(t.Context(), Env("APP_", EnvFiles()))
+		require.ErrorContains(t, err, "APP_PASSWORD and APP_PASSWORD_FILE are both set")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Setenv("APP_PASSWORD_FILE", "/path/does/not/exist")
+		_, err := manager.Load(t.Context(), Env("APP_", EnvFiles()))
+		require.ErrorContains(t, err, "APP_PASSWORD_FILE: read /path/does/not/exist")
+	})
+
+	t.Run("invalid content", func(t *testing.T) {
+		path := writeTempConfig(t, "a,b\n")
+		t.Setenv("APP_TAGS_FILE", path)
+		_, err := manager.Load(t.Context(), Env("APP_", EnvFiles()))
+		require.ErrorContains(t, err, "APP_TAGS_FILE")
+		require.ErrorContains(t, err, "JSON")
+	})
+}
+
+func TestManagerActionHonorsEnvFiles(t *testing.T) {
+	path := writeTempConfig(t, "from-file\n")
+	t.Setenv("APP_SERVER_REDIS_PASSWORD_FILE", path)
+
+	cfg, err := runManagerWithRootArgs(t, New(bindingDefaults(), WithoutDefaultPaths(), WithEnvFiles()), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", cfg.Server.Redis.Password)
+
+	cfg, err = runManagerWithRootArgs(t, New(bindingDefaults(), WithoutDefaultPaths()), nil)
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Server.Redis.Password)
+}
+
 func TestManagerRejectsDeepUnknownFields(t *testing.T) {
 	type Config struct {
 		Routes []bindingRoute `json:"routes"`
@@ -522,6 +590,20 @@ func TestManagerConfigureRejectsInvalidCommandTrees(t *testing.T) {
 	require.ErrorContains(t, root.Run(t.Context(), []string{"app", "other"}), "was not configured")
 }
 
+func TestManagerConfigureRejectsReservedRootFieldNames(t *testing.T) {
+	type reservedConfig struct {
+		Set        string `json:"set"`
+		SecretsDir string `json:"secrets-dir"`
+	}
+	err := New(reservedConfig{}).Configure(&cli.Command{Name: "app"})
+	require.ErrorContains(t, err, "config field set conflicts with reserved CLI flag --set")
+
+	err = New(reservedConfig{}, HideCLI("set")).Configure(&cli.Command{Name: "app"})
+	require.ErrorContains(t, err, "config field secrets-dir conflicts with reserved CLI flag --secrets-dir")
+
+	require.NoError(t, New(reservedConfig{}, HideCLI("set"), HideCLI("secrets-dir")).Configure(&cli.Command{Name: "app"}))
+}
+
 func TestManagerActionRejectsAnUnconfiguredCommandTree(t *testing.T) {
 	manager := New(bindingDefaults())
 	configured := &cli.Command{
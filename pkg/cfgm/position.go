@@ -0,0 +1,195 @@
+package cfgm
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lwmacct/251207-go-pkg-cfgm/pkg/templexp"
+	yamlv3 "go.yaml.in/yaml/v3"
+)
+
+// sourceDocument is a config file read during one load, kept so errors in
+// its values can be located.
+type sourceDocument struct {
+	format  string
+	content []byte
+}
+
+// valueOrigin names the file a layer was decoded from and the key path of
+// the section of the file holding the layer, such as "profiles.dev".
+type valueOrigin struct {
+	file    string
+	section string
+}
+
+// valueOrigins records which file set each value of the effective config.
+// Slices are recorded as a whole because merging replaces them.
+type valueOrigins struct {
+	documents map[string]sourceDocument
+	leaves    map[string]valueOrigin
+}
+
+func newValueOrigins() *valueOrigins {
+	return &valueOrigins{documents: make(map[string]sourceDocument), leaves: make(map[string]valueOrigin)}
+}
+
+// record marks the values of layer as set by its file, or by no file.
+func (o *valueOrigins) record(layer sourceLayer) {
+	var walk func(value any, path string)
+	walk = func(value any, path string) {
+		if object, ok := value.(map[string]any); ok {
+			for key, child := range object {
+				walk(child, templateMapPath(path, key))
+			}
+			return
+		}
+		if layer.origin.file == "" {
+			delete(o.leaves, path)
+			return
+		}
+		o.leaves[path] = layer.origin
+	}
+	walk(layer.data, "")
+}
+
+// locate returns file:line:col of the template text at the config path
+// where err occurred, or "" when the value did not come from a file. The
+// position points into the template for templexp errors and at the value
+// otherwise, or is just the file name for formats without YAML positions.
+func (o *valueOrigins) locate(path, text string, err error) string {
+	if o == nil {
+		return ""
+	}
+	origin, ok := o.origin(path)
+	if !ok {
+		return ""
+	}
+	document := o.documents[origin.file]
+	if document.format != "yaml" && document.format != "json" {
+		return origin.file
+	}
+	var root yamlv3.Node
+	if yamlv3.Unmarshal(document.content, &root) != nil {
+		return origin.file
+	}
+	node := findYAMLNode(&root, configPathSegments(templateMapPath(origin.section, path)))
+	if node == nil {
+		return origin.file
+	}
+
+	line, column := node.Line, node.Column
+	if offset, ok := templateErrorOffset(err); ok {
+		line, column = templateSourcePosition(node, string(document.content), text, offset)
+	}
+	return fmt.Sprintf("%s:%d:%d", origin.file, line, column)
+}
+
+// origin returns the origin of path or of the nearest ancestor recorded.
+func (o *valueOrigins) origin(path string) (valueOrigin, bool) {
+	for path != "" {
+		if origin, ok := o.leaves[path]; ok {
+			return origin, true
+		}
+		path = path[:max(strings.LastIndexAny(path, ".["), 0)]
+	}
+	return valueOrigin{}, false
+}
+
+// templateErrorOffset returns the byte offset in the template reported by a
+// templexp error. A resolver error is checked first because a resolver may
+// return the templexp error of another text.
+func templateErrorOffset(err error) (int, bool) {
+	var resolverErr *templexp.ResolverError
+	if errors.As(err, &resolverErr) {
+		return resolverErr.Offset, true
+	}
+	var syntaxErr *templexp.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return syntaxErr.Offset, true
+	}
+	var requiredErr *templexp.RequiredError
+	if errors.As(err, &requiredErr) {
+		return requiredErr.Offset, true
+	}
+	return 0, false
+}
+
+// templateSourcePosition maps offset in text, the decoded value of node, to a
+// position in content. Positions inside plain, quoted, and literal block
+// scalars are exact when the source spells the value verbatim up to offset;
+// otherwise the position of the value is returned.
+func templateSourcePosition(node *yamlv3.Node, content, text string, offset int) (int, int) {
+	lines := strings.Split(content, "\n")
+	offset = min(max(offset, 0), len(text))
+	line, column := textPosition(text, offset)
+	lineStart := offset - column + 1
+	prefix := text[lineStart:offset]
+	templateLine, _, _ := strings.Cut(text[lineStart:], "\n")
+
+	switch {
+	case node.Style&(yamlv3.LiteralStyle|yamlv3.FoldedStyle) == 0:
+		start := node.Column - 1
+		if node.Style&(yamlv3.SingleQuotedStyle|yamlv3.DoubleQuotedStyle) != 0 {
+			start++
+		}
+		if line == 1 && node.Line <= len(lines) && start <= len(lines[node.Line-1]) &&
+			strings.HasPrefix(lines[node.Line-1][start:], prefix) {
+			return node.Line, start + column
+		}
+	case node.Style&yamlv3.LiteralStyle != 0:
+		if index := node.Line - 1 + line; index < len(lines) {
+			source := strings.TrimSuffix(lines[index], "\r")
+			if strings.HasSuffix(source, templateLine) {
+				return index + 1, len(source) - len(templateLine) + column
+			}
+		}
+	}
+	return node.Line, node.Column
+}
+
+// configPathSegments splits a config path such as a.b[1].c into its keys
+// and slice indexes.
+func configPathSegments(path string) []string {
+	var segments []string
+	for key := range strings.SplitSeq(path, ".") {
+		name, indexes, _ := strings.Cut(key, "[")
+		segments = append(segments, name)
+		if indexes != "" {
+			segments = append(segments, strings.Split(strings.TrimSuffix(indexes, "]"), "][")...)
+		}
+	}
+	return segments
+}
+
+// findYAMLNode returns the node at segments below root, following aliases.
+func findYAMLNode(root *yamlv3.Node, segments []string) *yamlv3.Node {
+	node := root
+	if node.Kind == yamlv3.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	for _, segment := range segments {
+		for node.Kind == yamlv3.AliasNode {
+			node = node.Alias
+		}
+		var next *yamlv3.Node
+		switch node.Kind {
+		case yamlv3.MappingNode:
+			for index := 0; index+1 < len(node.Content); index += 2 {
+				if node.Content[index].Value == segment {
+					next = node.Content[index+1]
+				}
+			}
+		case yamlv3.SequenceNode:
+			if index, err := strconv.Atoi(segment); err == nil && index >= 0 && index < len(node.Content) {
+				next = node.Content[index]
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
@@ -0,0 +1,147 @@
+package cfgm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+// profilesKey is the top-level key holding per-profile sections when
+// WithProfiles is set.
+const profilesKey = "profiles"
+
+const profileFlagName = "profile"
+
+// WithProfiles enables config profiles such as prod or staging. For every
+// file loaded by File, Files, DefaultPaths or --config, each active profile
+// in order applies the section under profiles.<name> in that file and then
+// the overlay file <base>.<name>.<ext> next to it, when present.
+//
+// Profiles are selected by the root --profile flag, which may be repeated or
+// comma separated, and otherwise by envVar. Manager.Load reads envVar only.
+// An empty envVar disables selection from the environment.
+func WithProfiles(envVar string) Option {
+	return managerOptionFunc(func(options *managerOptions) {
+		options.profiles = true
+		options.profileEnv = strings.TrimSpace(envVar)
+	})
+}
+
+func profileFlag(envVar string) cli.Flag {
+	flag := &cli.StringSliceFlag{Name: profileFlagName, Usage: "启用的配置 profile, 按顺序叠加, 可重复"}
+	if envVar != "" {
+		flag.Sources = cli.EnvVars(envVar)
+	}
+	return flag
+}
+
+// envProfiles returns the profiles selected by the environment.
+func (m *Manager[T]) envProfiles() ([]string, error) {
+	if !m.profiles || m.profileEnv == "" {
+		return nil, nil
+	}
+	return parseProfiles([]string{os.Getenv(m.profileEnv)})
+}
+
+// commandProfiles returns the profiles selected by --profile or its
+// environment variable.
+func (m *Manager[T]) commandProfiles(cmd *cli.Command) ([]string, error) {
+	if !m.profiles {
+		return nil, nil
+	}
+	for _, command := range cmd.Lineage() {
+		if command.IsSet(profileFlagName) {
+			return parseProfiles(command.StringSlice(profileFlagName))
+		}
+	}
+	return nil, nil
+}
+
+func parseProfiles(values []string) ([]string, error) {
+	var profiles []string
+	for _, value := range values {
+		for profile := range strings.SplitSeq(value, ",") {
+			profile = strings.TrimSpace(profile)
+			if profile == "" {
+				continue
+			}
+			if profile == "." || profile == ".." || strings.ContainsAny(profile, `/\`) {
+				return nil, fmt.Errorf("invalid profile %q", profile)
+			}
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles, nil
+}
+
+// takeProfileSections removes the profiles key from data and returns its
+// sections.
+func takeProfileSections(schema Schema, path string, data map[string]any) (map[string]map[string]any, error) {
+	raw, exists := data[profilesKey]
+	if !schema.profileSections || !exists {
+		return nil, nil
+	}
+	delete(data, profilesKey)
+
+	sections, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: %s must be a mapping of profile names", path, profilesKey)
+	}
+	out := make(map[string]map[string]any, len(sections))
+	for profile, section := range sections {
+		switch typed := section.(type) {
+		case map[string]any:
+			out[profile] = typed
+		case nil:
+			out[profile] = map[string]any{}
+		default:
+			return nil, fmt.Errorf("%s: %s.%s must be a mapping", path, profilesKey, profile)
+		}
+	}
+	return out, nil
+}
+
+// loadProfileLayers returns, for each active profile, the section of the file
+// at path followed by the layers of its overlay file.
+func loadProfileLayers(
+	ctx context.Context, schema Schema, path, format string, sections map[string]map[string]any,
+) ([]sourceLayer, error) {
+	var layers []sourceLayer
+	for _, profile := range schema.profiles {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if section, ok := sections[profile]; ok {
+			layers = append(layers, sourceLayer{
+				name: "file:" + path + "#" + profile, data: section,
+				origin: valueOrigin{file: path, section: profilesKey + "." + profile},
+			})
+		}
+
+		overlay := profileOverlayPath(path, profile)
+		configMap, err := readConfigFile(schema, overlay, format)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		included, err := loadIncludes(ctx, schema, "file:"+overlay, overlay, configMap, nil)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, included...)
+	}
+	return layers, nil
+}
+
+// profileOverlayPath inserts profile before the extension of path, so
+// config.yaml becomes config.prod.yaml.
+func profileOverlayPath(path, profile string) string {
+	extension := filepath.Ext(path)
+	return strings.TrimSuffix(path, extension) + "." + profile + extension
+}
@@ -0,0 +1,110 @@
+package cfgm
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+)
+
+func writeProfileConfigs(t *testing.T) string {
+	t.Helper()
+	return writeConfigDir(t, map[string]string{
+		"config.yaml": `server:
+  addr: :8000
+  workers: 1
+profiles:
+  prod:
+    server:
+      workers: 8
+  staging:
+    server:
+      addr: :8100
+`,
+		"config.prod.yaml":    "server:\n  addr: :80\n",
+		"config.canary.yaml":  "server:\n  debug: true\n",
+		"config.staging.yaml": "server:\n  tags: [staging]\n",
+	})
+}
+
+func TestManagerLoadAppliesProfilesFromEnv(t *testing.T) {
+	dir := writeProfileConfigs(t)
+	path := filepath.Join(dir, "config.yaml")
+	manager := New(bindingDefaults(), WithoutDefaultPaths(), WithProfiles("APP_PROFILE"))
+
+	cfg, report, err := manager.LoadReport(t.Context(), File(path))
+	require.NoError(t, err)
+	assert.Equal(t, ":8000", cfg.Server.Addr)
+	assert.Equal(t, 1, cfg.Server.Workers)
+	assert.Empty(t, report.Profiles)
+	assert.Len(t, report.Sources, 1)
+
+	t.Setenv("APP_PROFILE", "prod, canary")
+	cfg, report, err = manager.LoadReport(t.Context(), File(path))
+	require.NoError(t, err)
+	assert.Equal(t, ":80", cfg.Server.Addr)
+	assert.Equal(t, 8, cfg.Server.Workers)
+	assert.True(t, cfg.Server.Debug)
+	assert.Equal(t, []string{"prod", "canary"}, report.Profiles)
+	assert.Equal(t, []SourceReport{
+		{Name: "file:" + path, Keys: []string{"server.addr", "server.workers"}},
+		{Name: "file:" + path + "#prod", Keys: []string{"server.workers"}},
+		{Name: "file:" + filepath.Join(dir, "config.prod.yaml"), Keys: []string{"server.addr"}},
+		{Name: "file:" + filepath.Join(dir, "config.canary.yaml"), Keys: []string{"server.debug"}},
+	}, report.Sources)
+
+	t.Setenv("APP_PROFILE", "staging,prod")
+	cfg, err = manager.Load(t.Context(), File(path))
+	require.NoError(t, err)
+	assert.Equal(t, ":80", cfg.Server.Addr, "later profiles win")
+	assert.Equal(t, []string{"staging"}, cfg.Server.Tags)
+
+	t.Setenv("APP_PROFILE", "../prod")
+	_, err = manager.Load(t.Context(), File(path))
+	require.ErrorContains(t, err, `invalid profile "../prod"`)
+}
+
+func TestManagerActionProfileFlag(t *testing.T) {
+	dir := writeProfileConfigs(t)
+	path := filepath.Join(dir, "config.yaml")
+	t.Setenv("APP_PROFILE", "staging")
+	manager := New(bindingDefaults(), WithoutDefaultPaths(), WithProfiles("APP_PROFILE"))
+
+	cfg, err := runManagerWithRootArgs(t, manager, []string{"--config", path})
+	require.NoError(t, err)
+	assert.Equal(t, ":8100", cfg.Server.Addr)
+
+	manager = New(bindingDefaults(), WithoutDefaultPaths(), WithProfiles("APP_PROFILE"))
+	cfg, err = runManagerWithRootArgs(t, manager, []string{"--config", path, "--profile", "prod", "--profile", "canary"})
+	require.NoError(t, err)
+	assert.Equal(t, ":80", cfg.Server.Addr)
+	assert.Equal(t, 8, cfg.Server.Workers)
+	assert.True(t, cfg.Server.Debug)
+	assert.Equal(t, []string{"default"}, cfg.Server.Tags, "--profile replaces APP_PROFILE")
+}
+
+func TestProfileErrors(t *testing.T) {
+	manager := New(bindingDefaults(), WithoutDefaultPaths(), WithProfiles(""))
+
+	_, err := manager.Load(t.Context(), File(writeTempConfig(t, "profiles: [prod]\n")))
+	require.ErrorContains(t, err, "profiles must be a mapping of profile names")
+
+	_, err = manager.Load(t.Context(), File(writeTempConfig(t, "profiles:\n  prod: 1\n")))
+	require.ErrorContains(t, err, "profiles.prod must be a mapping")
+
+	_, err = New(bindingDefaults(), WithoutDefaultPaths()).Load(t.Context(), File(writeTempConfig(t, "profiles: {}\n")))
+	require.ErrorContains(t, err, "profiles")
+
+	type profileConfig struct {
+		Profiles []string `json:"profiles"`
+	}
+	assert.Panics(t, func() { New(profileConfig{}, WithProfiles("APP_PROFILE")) })
+
+	type profileFlagConfig struct {
+		Profile string `json:"profile"`
+	}
+	err = New(profileFlagConfig{}, WithProfiles("APP_PROFILE")).Configure(&cli.Command{Name: "app"})
+	require.ErrorContains(t, err, "config field profile conflicts with reserved CLI flag --profile")
+}
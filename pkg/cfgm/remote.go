@@ -0,0 +1,225 @@
+package cfgm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultRemoteMaxBytes limits remote config documents unless RemoteMaxBytes
+// is set.
+const defaultRemoteMaxBytes = 10 << 20
+
+type remoteSource struct {
+	url      *url.URL
+	err      error
+	client   *http.Client
+	header   http.Header
+	format   string
+	timeout  time.Duration
+	maxBytes int64
+
+	mu    sync.Mutex
+	cache *remoteResponse
+}
+
+// remoteResponse is the last successful response, reused when the server
+// answers a conditional request with 304 Not Modified.
+type remoteResponse struct {
+	body         []byte
+	contentType  string
+	etag         string
+	lastModified string
+}
+
+// Remote loads a config document with an HTTP GET. The format comes from
+// RemoteFormat, else the Content-Type subtype (application/json,
+// application/yaml, application/vnd.app+json), else the URL path extension.
+//
+// The source remembers the ETag and Last-Modified of the last response and
+// sends If-None-Match and If-Modified-Since on later loads, so reloads reuse
+// the cached document when the server answers 304 Not Modified. Requests use
+// the Load context; RemoteTimeout adds a per-request limit. An unparsable URL
+// or a scheme other than http and https is reported by Load.
+func Remote(rawURL string, opts ...RemoteOption) Source {
+	source := &remoteSource{
+		client:   http.DefaultClient,
+		header:   make(http.Header),
+		maxBytes: defaultRemoteMaxBytes,
+	}
+	parsed, err := url.Parse(rawURL)
+	switch {
+	case err != nil:
+		source.err = fmt.Errorf("invalid remote URL: %w", err)
+	case parsed.Scheme != "http" && parsed.Scheme != "https":
+		source.url = parsed
+		source.err = fmt.Errorf("remote URL %s must use http or https", parsed.Redacted())
+	default:
+		source.url = parsed
+	}
+	for _, opt := range opts {
+		opt(source)
+	}
+
+	return source
+}
+
+// RemoteOption configures Remote sources.
+type RemoteOption func(*remoteSource)
+
+// RemoteHeader adds a request header.
+func RemoteHeader(name, value string) RemoteOption {
+	return func(s *remoteSource) {
+		s.header.Add(name, value)
+	}
+}
+
+// RemoteBearerToken sends an Authorization: Bearer header.
+func RemoteBearerToken(token string) RemoteOption {
+	return func(s *remoteSource) {
+		s.header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// RemoteFormat parses responses with the named Format regardless of
+// Content-Type.
+func RemoteFormat(name string) RemoteOption {
+	return func(s *remoteSource) {
+		s.format = strings.TrimSpace(name)
+	}
+}
+
+// RemoteTimeout bounds each request in addition to the Load context.
+func RemoteTimeout(timeout time.Duration) RemoteOption {
+	return func(s *remoteSource) {
+		s.timeout = timeout
+	}
+}
+
+// RemoteMaxBytes rejects responses larger than limit bytes. The default is
+// 10 MiB.
+func RemoteMaxBytes(limit int64) RemoteOption {
+	if limit <= 0 {
+		panic("cfgm: remote size limit must be positive")
+	}
+	return func(s *remoteSource) {
+		s.maxBytes = limit
+	}
+}
+
+// RemoteClient sends requests with client instead of http.DefaultClient.
+func RemoteClient(client *http.Client) RemoteOption {
+	if client == nil {
+		panic("cfgm: remote client must not be nil")
+	}
+	return func(s *remoteSource) {
+		s.client = client
+	}
+}
+
+func (s *remoteSource) Name() string {
+	if s.url == nil {
+		return "remote"
+	}
+	return "remote:" + s.url.Redacted()
+}
+
+func (s *remoteSource) Load(ctx context.Context, schema Schema) (map[string]any, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	response, err := s.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := s.resolveFormat(schema, response.contentType)
+	if err != nil {
+		return nil, err
+	}
+	configMap, err := decodeConfigBytes(format, response.body)
+	if err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+
+	return configMap, nil
+}
+
+func (s *remoteSource) fetch(ctx context.Context) (*remoteResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header = s.header.Clone()
+	if s.cache != nil {
+		if s.cache.etag != "" {
+			request.Header.Set("If-None-Match", s.cache.etag)
+		}
+		if s.cache.lastModified != "" {
+			request.Header.Set("If-Modified-Since", s.cache.lastModified)
+		}
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotModified && s.cache != nil {
+		return s.cache, nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", response.Status)
+	}
+	if response.ContentLength > s.maxBytes {
+		return nil, fmt.Errorf("response exceeds %d bytes", s.maxBytes)
+	}
+	body, err := io.ReadAll(io.LimitReader(response.Body, s.maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if int64(len(body)) > s.maxBytes {
+		return nil, fmt.Errorf("response exceeds %d bytes", s.maxBytes)
+	}
+
+	s.cache = &remoteResponse{
+		body:         body,
+		contentType:  response.Header.Get("Content-Type"),
+		etag:         response.Header.Get("ETag"),
+		lastModified: response.Header.Get("Last-Modified"),
+	}
+
+	return s.cache, nil
+}
+
+func (s *remoteSource) resolveFormat(schema Schema, contentType string) (Format, error) {
+	if s.format != "" {
+		return schema.formats.resolve(s.format, "")
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		subtype := mediaType[strings.LastIndex(mediaType, "/")+1:]
+		if index := strings.LastIndex(subtype, "+"); index >= 0 {
+			subtype = subtype[index+1:]
+		}
+		if format, ok := schema.formats.byName(strings.TrimPrefix(subtype, "x-")); ok {
+			return format, nil
+		}
+	}
+
+	return schema.formats.byPath(s.url.Path)
+}
@@ -1,11 +1,28 @@
 package cfgm
 
-import "reflect"
+import (
+	"io/fs"
+	"maps"
+	"reflect"
+
+	"github.com/lwmacct/251207-go-pkg-cfgm/pkg/templexp"
+)
 
 type Schema struct {
-	model  *schemaModel
-	codecs map[reflect.Type]valueCodec
-	lookup func(string) (string, bool)
+	model        *schemaModel
+	codecs       map[reflect.Type]valueCodec
+	lookup       func(string) (string, bool)
+	formats      *formatSet
+	templateVars map[string]string
+	includes     bool
+	fsys         fs.FS
+
+	templateOptions []templexp.Option
+	// documents collects the config files read during a load.
+	documents map[string]sourceDocument
+
+	profileSections bool
+	profiles        []string
 }
 
 type Field struct {
@@ -28,3 +45,12 @@ func (s Schema) Fields() []Field {
 func (s Schema) Has(path string) bool {
 	return s.model != nil && s.model.hasPath(cleanConfigPath(path))
 }
+
+// addTemplateVariables makes variables visible to ${VAR} expansion for the
+// current load. The process environment takes precedence over them.
+func (s Schema) addTemplateVariables(variables map[string]string) {
+	if s.templateVars == nil {
+		return
+	}
+	maps.Copy(s.templateVars, variables)
+}
@@ -0,0 +1,252 @@
+package cfgm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// SearchScope groups search locations for SearchPaths.MergeAll.
+type SearchScope int
+
+const (
+	// ScopeSystem holds machine-wide locations such as /etc/<app>/config.
+	ScopeSystem SearchScope = iota
+	// ScopeUser holds per-user locations such as ~/.config/<app>/config.
+	ScopeUser
+	// ScopeProject holds locations relative to the working directory.
+	ScopeProject
+)
+
+// SearchLocation is a config file path without its extension. Each location
+// is probed with the extensions of the searched formats.
+type SearchLocation struct {
+	Scope SearchScope
+	Base  string
+}
+
+// SearchPaths builds the list of optional config files a Manager probes
+// before explicit sources. Locations are listed by priority: by default the
+// first existing file wins, as with Files.
+type SearchPaths struct {
+	locations []SearchLocation
+	formats   []string
+	mergeAll  bool
+}
+
+// NewSearchPaths returns the default locations for appName, highest priority
+// first:
+//
+//	./.<app>.*
+//	~/.<app>.*
+//	$XDG_CONFIG_HOME/<app>/config.*  (default ~/.config)
+//	$XDG_CONFIG_DIRS/<app>/config.*  (default /etc/xdg)
+//	/etc/<app>/config.*
+//	./config.*
+//	./config/config.*
+//
+// Without appName only the last two are searched.
+func NewSearchPaths(appName string) *SearchPaths {
+	p := &SearchPaths{}
+	if appName != "" {
+		p.Add(ScopeProject, "."+appName)
+		home, err := os.UserHomeDir()
+		if err == nil {
+			p.Add(ScopeUser, filepath.Join(home, "."+appName))
+		}
+		if configHome := xdgConfigHome(home); configHome != "" {
+			p.Add(ScopeUser, filepath.Join(configHome, appName, "config"))
+		}
+		for _, dir := range xdgConfigDirs() {
+			p.Add(ScopeSystem, filepath.Join(dir, appName, "config"))
+		}
+		p.Add(ScopeSystem, "/etc/"+appName+"/config")
+	}
+	p.Add(ScopeProject, "config")
+	p.Add(ScopeProject, filepath.Join("config", "config"))
+	return p
+}
+
+// Add appends a location with the lowest priority.
+func (p *SearchPaths) Add(scope SearchScope, base string) *SearchPaths {
+	p.locations = append(p.locations, SearchLocation{Scope: scope, Base: base})
+	return p
+}
+
+// Remove drops every location with the given base.
+func (p *SearchPaths) Remove(base string) *SearchPaths {
+	base = filepath.Clean(base)
+	p.locations = slices.DeleteFunc(p.locations, func(location SearchLocation) bool {
+		return filepath.Clean(location.Base) == base
+	})
+	return p
+}
+
+// Formats probes only the named formats, in the given order, instead of
+// every registered format in registration order.
+func (p *SearchPaths) Formats(names ...string) *SearchPaths {
+	p.formats = nil
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			p.formats = append(p.formats, name)
+		}
+	}
+	return p
+}
+
+// MergeAll loads every location that has a config file instead of stopping
+// at the first one. Files are merged system, then user, then project scope,
+// and within a scope earlier locations override later ones. Each file is
+// listed in Report.Sources.
+func (p *SearchPaths) MergeAll() *SearchPaths {
+	p.mergeAll = true
+	return p
+}
+
+// Locations returns the configured locations by priority.
+func (p *SearchPaths) Locations() []SearchLocation {
+	return slices.Clone(p.locations)
+}
+
+// Paths returns every probed file path by priority, using the globally
+// registered formats. Unknown format names are skipped.
+func (p *SearchPaths) Paths() []string {
+	var paths []string
+	for _, location := range p.locations {
+		paths = append(paths, p.locationPaths(location, p.extensions(nil))...)
+	}
+	return paths
+}
+
+// Source returns a source loading the configured locations. Missing files
+// are skipped.
+func (p *SearchPaths) Source() Source {
+	return &searchSource{paths: p.clone()}
+}
+
+func (p *SearchPaths) clone() *SearchPaths {
+	return &SearchPaths{locations: slices.Clone(p.locations), formats: slices.Clone(p.formats), mergeAll: p.mergeAll}
+}
+
+func (p *SearchPaths) extensions(formats *formatSet) []string {
+	if len(p.formats) == 0 {
+		return formats.extensions()
+	}
+	var extensions []string
+	for _, name := range p.formats {
+		format, ok := formats.byName(name)
+		if !ok {
+			continue
+		}
+		for _, extension := range format.Extensions() {
+			if !slices.Contains(extensions, extension) {
+				extensions = append(extensions, extension)
+			}
+		}
+	}
+	return extensions
+}
+
+func (p *SearchPaths) locationPaths(location SearchLocation, extensions []string) []string {
+	return appendConfigFormats(nil, location.Base, extensions)
+}
+
+// mergeOrder returns the locations from lowest to highest priority.
+func (p *SearchPaths) mergeOrder() []SearchLocation {
+	var ordered []SearchLocation
+	for _, scope := range []SearchScope{ScopeSystem, ScopeUser, ScopeProject} {
+		for _, location := range slices.Backward(p.locations) {
+			if location.Scope == scope {
+				ordered = append(ordered, location)
+			}
+		}
+	}
+	return ordered
+}
+
+func xdgConfigHome(home string) string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+		return dir
+	}
+	if home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".config")
+}
+
+func xdgConfigDirs() []string {
+	var dirs []string
+	for _, dir := range filepath.SplitList(os.Getenv("XDG_CONFIG_DIRS")) {
+		if filepath.IsAbs(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) == 0 {
+		dirs = []string{"/etc/xdg"}
+	}
+	return dirs
+}
+
+type searchSource struct {
+	paths *SearchPaths
+}
+
+func (s *searchSource) Name() string {
+	if s.paths.mergeAll {
+		return "search-paths"
+	}
+	return "files"
+}
+
+func (s *searchSource) Load(ctx context.Context, schema Schema) (map[string]any, error) {
+	return mergeLayers(s.loadLayers(ctx, schema))
+}
+
+func (s *searchSource) loadLayers(ctx context.Context, schema Schema) ([]sourceLayer, error) {
+	for _, name := range s.paths.formats {
+		if _, err := schema.formats.resolve(name, ""); err != nil {
+			return nil, err
+		}
+	}
+	extensions := s.paths.extensions(schema.formats)
+	if !s.paths.mergeAll {
+		var paths []string
+		for _, location := range s.paths.locations {
+			paths = append(paths, s.paths.locationPaths(location, extensions)...)
+		}
+		return (&fileSource{paths: paths, optional: true}).loadLayers(ctx, schema)
+	}
+
+	var layers []sourceLayer
+	for _, location := range s.paths.mergeOrder() {
+		for _, path := range s.paths.locationPaths(location, extensions) {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			found, err := loadConfigFileLayers(ctx, schema, "file:"+path, path, "")
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			layers = append(layers, found...)
+			break
+		}
+	}
+	return layers, nil
+}
+
+// WithSearchPaths replaces the default search locations of Manager.Load and
+// Manager.Action with paths. WithoutDefaultPaths still disables searching.
+func WithSearchPaths(paths *SearchPaths) Option {
+	if paths == nil {
+		panic("cfgm: search paths must not be nil")
+	}
+	paths = paths.clone()
+	return managerOptionFunc(func(options *managerOptions) {
+		options.searchPaths = paths
+	})
+}
@@ -0,0 +1,102 @@
+package cfgm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSearchPathsHonorsXDG(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "/xdg/home")
+	t.Setenv("XDG_CONFIG_DIRS", "/xdg/a:relative:/xdg/b")
+
+	assert.Equal(t, []SearchLocation{
+		{Scope: ScopeProject, Base: ".app"},
+		{Scope: ScopeUser, Base: filepath.Join(home, ".app")},
+		{Scope: ScopeUser, Base: "/xdg/home/app/config"},
+		{Scope: ScopeSystem, Base: "/xdg/a/app/config"},
+		{Scope: ScopeSystem, Base: "/xdg/b/app/config"},
+		{Scope: ScopeSystem, Base: "/etc/app/config"},
+		{Scope: ScopeProject, Base: "config"},
+		{Scope: ScopeProject, Base: "config/config"},
+	}, NewSearchPaths("app").Locations())
+
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_CONFIG_DIRS", "")
+	locations := NewSearchPaths("app").Locations()
+	assert.Contains(t, locations, SearchLocation{Scope: ScopeUser, Base: filepath.Join(home, ".config", "app", "config")})
+	assert.Contains(t, locations, SearchLocation{Scope: ScopeSystem, Base: "/etc/xdg/app/config"})
+}
+
+func TestSearchPathsBuilder(t *testing.T) {
+	paths := NewSearchPaths("").
+		Remove("config/config").
+		Add(ScopeSystem, "/opt/app/config").
+		Formats("toml", "ini", "yaml")
+
+	assert.Equal(t, []string{
+		"config.toml", "config.yaml", "config.yml",
+		"/opt/app/config.toml", "/opt/app/config.yaml", "/opt/app/config.yml",
+	}, paths.Paths())
+
+	_, err := New(bindingDefaults(), WithoutDefaultPaths()).Load(t.Context(), paths.Source())
+	require.ErrorContains(t, err, `unknown config format "ini"`)
+
+	assert.Panics(t, func() { WithSearchPaths(nil) })
+}
+
+func TestSearchPathsFirstMatchAndMergeAll(t *testing.T) {
+	root := writeConfigDir(t, map[string]string{
+		"etc/config.yaml":     "server:\n  addr: :1\n  workers: 1\n  debug: true\n",
+		"home/config.json":    `{"server": {"addr": ":2", "workers": 2}}`,
+		"home/config.yaml":    "server:\n  addr: :ignored\n",
+		"project/config.yaml": "server:\n  addr: :3\n",
+	})
+	t.Chdir(filepath.Join(root, "project"))
+	paths := NewSearchPaths("").
+		Add(ScopeUser, filepath.Join(root, "home", "config")).
+		Add(ScopeSystem, filepath.Join(root, "etc", "config")).
+		Formats("json", "yaml")
+
+	manager := New(bindingDefaults(), WithSearchPaths(paths))
+	cfg, report, err := manager.LoadReport(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, ":3", cfg.Server.Addr)
+	assert.False(t, cfg.Server.Debug, "first match stops at the project file")
+	assert.Equal(t, "files", report.Sources[0].Name)
+
+	paths.MergeAll()
+	cfg, _, err = manager.LoadReport(t.Context())
+	require.NoError(t, err)
+	assert.False(t, cfg.Server.Debug, "the manager keeps its own copy of the search paths")
+
+	manager = New(bindingDefaults(), WithSearchPaths(paths))
+	cfg, report, err = manager.LoadReport(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, ":3", cfg.Server.Addr)
+	assert.Equal(t, 2, cfg.Server.Workers)
+	assert.True(t, cfg.Server.Debug)
+	assert.Equal(t, []SourceReport{
+		{Name: "file:" + filepath.Join(root, "etc", "config.yaml"), Keys: []string{"server.addr", "server.debug", "server.workers"}},
+		{Name: "file:" + filepath.Join(root, "home", "config.json"), Keys: []string{"server.addr", "server.workers"}},
+		{Name: "file:config.yaml", Keys: []string{"server.addr"}},
+	}, report.Sources)
+
+	cfg, err = runManagerWithRootArgs(t, manager, nil, "--workers", "9")
+	require.NoError(t, err)
+	assert.Equal(t, ":3", cfg.Server.Addr)
+	assert.Equal(t, 9, cfg.Server.Workers)
+
+	require.NoError(t, os.Remove(filepath.Join(root, "project", "config.yaml")))
+	require.NoError(t, os.Remove(filepath.Join(root, "home", "config.json")))
+	require.NoError(t, os.Remove(filepath.Join(root, "etc", "config.yaml")))
+	require.NoError(t, os.Remove(filepath.Join(root, "home", "config.yaml")))
+	_, report, err = manager.LoadReport(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, []SourceReport{{Name: "search-paths"}}, report.Sources)
+}
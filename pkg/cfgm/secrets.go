@@ -0,0 +1,151 @@
+package cfgm
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+type secretsDirSource struct {
+	dir      string
+	prefix   string
+	mapping  map[string]string
+	optional bool
+}
+
+// SecretsDir loads one value per file from dir, as mounted by Kubernetes
+// secret volumes or exposed by systemd in $CREDENTIALS_DIRECTORY.
+//
+// A file named like an environment variable, prefix + SERVER_REDIS_PASSWORD,
+// sets server.redis.password. One trailing newline is trimmed, and values are
+// parsed like Env values. Files that name no config field are ignored, and the
+// files that were read are listed in SourceReport.Files.
+func SecretsDir(dir string, opts ...SecretsOption) Source {
+	source := &secretsDirSource{dir: dir}
+	for _, opt := range opts {
+		opt(source)
+	}
+
+	return source
+}
+
+// WithSecretsDirEnv makes the root --secrets-dir flag fall back to the
+// directory named by envVar, such as "CREDENTIALS_DIRECTORY" for systemd
+// credentials. Without it --secrets-dir is read from the command line only.
+func WithSecretsDirEnv(envVar string) Option {
+	return managerOptionFunc(func(options *managerOptions) {
+		options.secretsDirEnv = strings.TrimSpace(envVar)
+	})
+}
+
+// SecretsOption configures SecretsDir sources.
+type SecretsOption func(*secretsDirSource)
+
+// SecretsPrefix requires file names to start with prefix, as Env does for
+// variable names.
+func SecretsPrefix(prefix string) SecretsOption {
+	return func(s *secretsDirSource) {
+		s.prefix = prefix
+	}
+}
+
+// SecretsMapping maps file names to config field paths, for mounts whose file
+// names do not follow the environment naming, such as "db-password". Mapped
+// names take precedence over the default naming.
+func SecretsMapping(mapping map[string]string) SecretsOption {
+	return func(s *secretsDirSource) {
+		if s.mapping == nil {
+			s.mapping = make(map[string]string, len(mapping))
+		}
+		for name, path := range mapping {
+			s.mapping[name] = cleanConfigPath(path)
+		}
+	}
+}
+
+// SecretsOptional allows the directory to be absent.
+func SecretsOptional() SecretsOption {
+	return func(s *secretsDirSource) {
+		s.optional = true
+	}
+}
+
+func (s *secretsDirSource) Name() string {
+	return "secrets:" + s.dir
+}
+
+func (s *secretsDirSource) Load(ctx context.Context, schema Schema) (map[string]any, error) {
+	return mergeLayers(s.loadLayers(ctx, schema))
+}
+
+func (s *secretsDirSource) loadLayers(ctx context.Context, schema Schema) ([]sourceLayer, error) {
+	fields := make(map[string]Field)
+	byName := make(map[string]Field)
+	for _, field := range schema.Fields() {
+		fields[field.Path] = field
+		byName[s.prefix+envName(field.Path)] = field
+	}
+	for _, name := range slices.Sorted(maps.Keys(s.mapping)) {
+		field, ok := fields[s.mapping[name]]
+		if !ok {
+			return nil, fmt.Errorf("secret %s maps to unknown config field %q", name, s.mapping[name])
+		}
+		byName[name] = field
+	}
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) && s.optional {
+			return []sourceLayer{{name: s.Name(), data: map[string]any{}}}, nil
+		}
+		return nil, fmt.Errorf("read dir %s: %w", s.dir, err)
+	}
+
+	layer := sourceLayer{name: s.Name(), data: map[string]any{}}
+	assigned := make(map[string]string)
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		name := entry.Name()
+		field, ok := byName[name]
+		// Kubernetes keeps the real files in hidden ..data directories and
+		// links each key into the mount root.
+		if !ok || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if previous, exists := assigned[field.Path]; exists {
+			return nil, fmt.Errorf("secrets %s and %s both set %s", previous, name, field.Path)
+		}
+
+		path := filepath.Join(s.dir, name)
+		value, err := readSecretFile(path)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := schema.parseEnvValue(field, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		setByPath(layer.data, field.Path, parsed)
+		assigned[field.Path] = name
+		layer.files = append(layer.files, path)
+	}
+
+	return []sourceLayer{layer}, nil
+}
+
+// readSecretFile reads a single-value file and trims one trailing newline.
+func readSecretFile(path string) (string, error) {
+	content, err := os.ReadFile(path) //nolint:gosec // path is provided by the caller
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", path, err)
+	}
+	value := strings.TrimSuffix(string(content), "\n")
+
+	return strings.TrimSuffix(value, "\r"), nil
+}
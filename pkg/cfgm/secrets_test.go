@@ -0,0 +1,110 @@
+package cfgm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretsDirMapsFilesToFields(t *testing.T) {
+	dir := writeConfigDir(t, map[string]string{
+		"SERVER_REDIS_PASSWORD": "s3cret\n",
+		"SERVER_TAGS":           `["a","b"]` + "\r\n",
+		"SERVER_WORKERS":        "8",
+		"UNRELATED":             "ignored",
+		"..data/SERVER_ADDR":    ":1",
+	})
+
+	cfg, report, err := New(bindingDefaults(), WithoutDefaultPaths()).LoadReport(t.Context(), SecretsDir(dir))
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", cfg.Server.Redis.Password)
+	assert.Equal(t, []string{"a", "b"}, cfg.Server.Tags)
+	assert.Equal(t, 8, cfg.Server.Workers)
+	assert.Equal(t, ":8080", cfg.Server.Addr)
+	require.Len(t, report.Sources, 1)
+	assert.Equal(t, SourceReport{
+		Name: "secrets:" + dir,
+		Keys: []string{"server.redis.password", "server.tags", "server.workers"},
+		Files: []string{
+			filepath.Join(dir, "SERVER_REDIS_PASSWORD"),
+			filepath.Join(dir, "SERVER_TAGS"),
+			filepath.Join(dir, "SERVER_WORKERS"),
+		},
+	}, report.Sources[0])
+}
+
+func TestSecretsDirPrefixAndMapping(t *testing.T) {
+	dir := writeConfigDir(t, map[string]string{
+		"APP_SERVER_ADDR": ":9090",
+		"SERVER_WORKERS":  "8",
+		"redis-password":  "mapped\n\n",
+	})
+
+	cfg, err := New(bindingDefaults(), WithoutDefaultPaths()).Load(t.Context(), SecretsDir(dir,
+		SecretsPrefix("APP_"),
+		SecretsMapping(map[string]string{"redis-password": "server.redis.password"}),
+	))
+	require.NoError(t, err)
+	assert.Equal(t, ":9090", cfg.Server.Addr)
+	assert.Equal(t, 2, cfg.Server.Workers)
+	assert.Equal(t, "mapped\n", cfg.Server.Redis.Password)
+}
+
+func TestSecretsDirErrors(t *testing.T) {
+	manager := New(bindingDefaults(), WithoutDefaultPaths())
+
+	t.Run("unknown mapping", func(t *testing.T) {
+		_, err := manager.Load(t.Context(), SecretsDir(t.TempDir(), SecretsMapping(map[string]string{"x": "server.nope"})))
+		require.ErrorContains(t, err, `secret x maps to unknown config field "server.nope"`)
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		dir := writeConfigDir(t, map[string]string{"SERVER_ADDR": ":1", "addr": ":2"})
+		_, err := manager.Load(t.Context(), SecretsDir(dir, SecretsMapping(map[string]string{"addr": "server.addr"})))
+		require.ErrorContains(t, err, "both set server.addr")
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		dir := writeConfigDir(t, map[string]string{"SERVER_TAGS": "a,b"})
+		_, err := manager.Load(t.Context(), SecretsDir(dir))
+		require.ErrorContains(t, err, filepath.Join(dir, "SERVER_TAGS"))
+	})
+
+	t.Run("missing dir", func(t *testing.T) {
+		missing := filepath.Join(t.TempDir(), "missing")
+		_, err := manager.Load(t.Context(), SecretsDir(missing))
+		require.ErrorContains(t, err, "read dir "+missing)
+
+		cfg, err := manager.Load(t.Context(), SecretsDir(missing, SecretsOptional()))
+		require.NoError(t, err)
+		assert.Equal(t, ":8080", cfg.Server.Addr)
+	})
+}
+
+func TestManagerActionLoadsSecretsDir(t *testing.T) {
+	dir := writeConfigDir(t, map[string]string{"SERVER_ADDR": ":7000\n", "SERVER_REDIS_PASSWORD": "pw"})
+	manager := New(bindingDefaults(), WithoutDefaultPaths())
+
+	t.Setenv("APP_SERVER_ADDR", ":6000")
+	cfg, err := runManagerWithRootArgs(t, manager, []string{"--secrets-dir", dir})
+	require.NoError(t, err)
+	assert.Equal(t, ":7000", cfg.Server.Addr)
+	assert.Equal(t, "pw", cfg.Server.Redis.Password)
+
+	cfg, err = runManagerWithRootArgs(t, New(bindingDefaults(), WithoutDefaultPaths()), []string{"--secrets-dir", dir}, "--addr", ":5000")
+	require.NoError(t, err)
+	assert.Equal(t, ":5000", cfg.Server.Addr)
+
+	require.NoError(t, os.Unsetenv("APP_SERVER_ADDR"))
+	t.Setenv("CREDENTIALS_DIRECTORY", dir)
+	cfg, err = runManagerWithRootArgs(t, New(bindingDefaults(), WithoutDefaultPaths()), nil)
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Server.Redis.Password)
+
+	cfg, err = runManagerWithRootArgs(t, New(bindingDefaults(), WithoutDefaultPaths(), WithSecretsDirEnv("CREDENTIALS_DIRECTORY")), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "pw", cfg.Server.Redis.Password)
+}
@@ -0,0 +1,108 @@
+package cfgm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+type setSource struct {
+	assignments []string
+}
+
+// Set applies path=value assignments to any config path, including paths
+// hidden from the CLI. Values are parsed like Env values: slices and maps as
+// JSON, codec types by their codec. A struct path takes a JSON object. Later
+// assignments to the same path win.
+func Set(assignments ...string) Source {
+	return &setSource{assignments: append([]string(nil), assignments...)}
+}
+
+func (s *setSource) Name() string {
+	return "set"
+}
+
+func (s *setSource) Load(ctx context.Context, schema Schema) (map[string]any, error) {
+	fields := make(map[string]Field)
+	for _, field := range schema.Fields() {
+		fields[field.Path] = field
+	}
+
+	out := map[string]any{}
+	for _, assignment := range s.assignments {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		path, value, ok := strings.Cut(assignment, "=")
+		path = cleanConfigPath(path)
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid assignment %q: expected path=value", assignment)
+		}
+
+		field, isField := fields[path]
+		switch {
+		case isField:
+			parsed, err := schema.parseEnvValue(field, value)
+			if err != nil {
+				return nil, err
+			}
+			setByPath(out, path, parsed)
+		case schema.Has(path):
+			var object map[string]any
+			if err := json.Unmarshal([]byte(value), &object); err != nil || object == nil {
+				return nil, fmt.Errorf("%s must be a JSON object", path)
+			}
+			setByPath(out, path, object)
+		default:
+			// Unknown paths are left to schema validation.
+			setByPath(out, path, value)
+		}
+	}
+
+	return out, nil
+}
+
+// assignmentsFlag is the --set flag. It clears the collected assignments
+// before each parse, so running a command again does not keep the values of
+// the previous run.
+type assignmentsFlag struct {
+	*cli.GenericFlag
+
+	value *assignmentsValue
+}
+
+func newAssignmentsFlag() *assignmentsFlag {
+	value := &assignmentsValue{}
+	return &assignmentsFlag{
+		GenericFlag: &cli.GenericFlag{Name: setFlagName, Usage: "覆盖任意配置路径, 格式 path=value, 可重复", Value: value},
+		value:       value,
+	}
+}
+
+func (f *assignmentsFlag) PreParse() error {
+	f.value.assignments = nil
+	return f.GenericFlag.PreParse()
+}
+
+// assignmentsValue collects repeated --set flags without splitting values on
+// commas, so JSON arrays and objects pass through intact.
+type assignmentsValue struct {
+	assignments []string
+}
+
+func (v *assignmentsValue) Set(raw string) error {
+	if _, _, ok := strings.Cut(raw, "="); !ok {
+		return fmt.Errorf("expected path=value, got %q", raw)
+	}
+	v.assignments = append(v.assignments, raw)
+	return nil
+}
+
+func (v *assignmentsValue) String() string {
+	return strings.Join(v.assignments, " ")
+}
+
+func (v *assignmentsValue) Get() any { return v }
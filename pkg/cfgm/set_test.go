@@ -0,0 +1,107 @@
+package cfgm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+)
+
+func TestSetSourceAssignsAnyPath(t *testing.T) {
+	manager := New(bindingDefaults(), WithoutDefaultPaths(), HideCLI("server.redis.password"))
+
+	cfg, report, err := manager.LoadReport(t.Context(), Set(
+		"server.redis.password=p=w",
+		"server.tags=[\"a\",\"b\"]",
+		"server.workers=4",
+		"server.addr=:1",
+		" server.addr =:2",
+		`server.certificates=[{"id":"main","refresh":"30s"}]`,
+		`server.redis={"url":"redis://set:6379"}`,
+	))
+	require.NoError(t, err)
+	assert.Equal(t, "", cfg.Server.Redis.Password, "struct assignment replaces earlier leaf assignments")
+	assert.Equal(t, "redis://set:6379", cfg.Server.Redis.URL)
+	assert.Equal(t, []string{"a", "b"}, cfg.Server.Tags)
+	assert.Equal(t, 4, cfg.Server.Workers)
+	assert.Equal(t, ":2", cfg.Server.Addr)
+	require.Len(t, cfg.Server.Certificates, 1)
+	assert.Equal(t, 30*time.Second, cfg.Server.Certificates[0].Refresh)
+	require.Len(t, report.Sources, 1)
+	assert.Equal(t, "set", report.Sources[0].Name)
+
+	cfg, err = manager.Load(t.Context(), Set("server.redis.password=p=w"))
+	require.NoError(t, err)
+	assert.Equal(t, "p=w", cfg.Server.Redis.Password)
+}
+
+func TestSetSourceErrors(t *testing.T) {
+	manager := New(bindingDefaults(), WithoutDefaultPaths())
+	tests := []struct {
+		assignment string
+		message    string
+	}{
+		{assignment: "server.addr", message: `invalid assignment "server.addr": expected path=value`},
+		{assignment: "=x", message: `invalid assignment "=x": expected path=value`},
+		{assignment: "server.tags=a,b", message: "parse server.tags as JSON"},
+		{assignment: "server.redis=url", message: "server.redis must be a JSON object"},
+		{assignment: "server.typo=1", message: "server.typo"},
+		{assignment: `server.redis={"typo":1}`, message: "server.redis.typo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.assignment, func(t *testing.T) {
+			_, err := manager.Load(t.Context(), Set(tt.assignment))
+			require.ErrorContains(t, err, "set: ")
+			require.ErrorContains(t, err, tt.message)
+		})
+	}
+}
+
+func TestManagerActionSetFlag(t *testing.T) {
+	t.Setenv("APP_SERVER_ADDR", ":6000")
+	cfg, err := runManagerWithRootArgs(t, New(bindingDefaults(), WithoutDefaultPaths(), HideCLI("server.redis.password")), []string{
+		"--set", "server.redis.password=secret",
+		"--set", `server.tags=["x","y"]`,
+		"--set", "server.addr=:7000",
+	}, "--addr", ":5000")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", cfg.Server.Redis.Password)
+	assert.Equal(t, []string{"x", "y"}, cfg.Server.Tags)
+	assert.Equal(t, ":7000", cfg.Server.Addr)
+
+	_, err = runManagerWithRootArgs(t, New(bindingDefaults(), WithoutDefaultPaths()), []string{"--set", "server.addr"})
+	require.ErrorContains(t, err, "expected path=value")
+
+	_, err = runManagerWithRootArgs(t, New(bindingDefaults(), WithoutDefaultPaths()), []string{"--set", "server.typo=1"})
+	require.ErrorContains(t, err, "server.typo")
+}
+
+func TestManagerSetFlagDoesNotRepeatAcrossCommandsOrRuns(t *testing.T) {
+	manager := New(bindingDefaults(), WithoutDefaultPaths())
+	var assignments []string
+	var loaded *bindingTestConfig
+	root := &cli.Command{
+		Name: "app",
+		Commands: []*cli.Command{{
+			Name: "server",
+			Action: manager.Action(func(_ context.Context, cmd *cli.Command, cfg *bindingTestConfig) error {
+				assignments = commandAssignments(cmd)
+				loaded = cfg
+				return nil
+			}),
+		}},
+	}
+	manager.MustConfigure(root)
+
+	require.NoError(t, root.Run(t.Context(), []string{"app", "--set", "server.addr=:7000", "server"}))
+	assert.Equal(t, []string{"server.addr=:7000"}, assignments)
+	assert.Equal(t, ":7000", loaded.Server.Addr)
+
+	require.NoError(t, root.Run(t.Context(), []string{"app", "--set", "server.workers=4", "server"}))
+	assert.Equal(t, []string{"server.workers=4"}, assignments)
+	assert.Equal(t, ":8080", loaded.Server.Addr)
+	assert.Equal(t, 4, loaded.Server.Workers)
+}
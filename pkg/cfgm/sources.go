@@ -6,21 +6,30 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
+
+	"github.com/lwmacct/251207-go-pkg-cfgm/pkg/templexp"
 )
 
 type fileSource struct {
 	paths    []string
 	optional bool
+	format   string
+	fsys     fs.FS
+	merge    bool
 }
 
 func File(path string, opts ...FileOption) Source {
 	return Files([]string{path}, opts...)
 }
 
-// Files loads the first existing file from paths.
+// Files loads the first existing file from paths. Paths may use ${VAR}
+// templates, a leading ~/ for the home directory, and glob patterns whose
+// matches are tried in lexical order. Report lists the file that was read.
 func Files(paths []string, opts ...FileOption) Source {
 	source := &fileSource{
 		paths: append([]string{}, paths...),
@@ -49,6 +58,23 @@ func Required() FileOption {
 	}
 }
 
+// MergeMatches loads every existing file matched by the paths, in order,
+// instead of only the first. Each file is reported as its own "file:<path>"
+// entry in Report.Sources.
+func MergeMatches() FileOption {
+	return func(s *fileSource) {
+		s.merge = true
+	}
+}
+
+// FileFormat parses files with the named Format instead of selecting one by
+// file extension.
+func FileFormat(name string) FileOption {
+	return func(s *fileSource) {
+		s.format = strings.TrimSpace(name)
+	}
+}
+
 func (s *fileSource) Name() string {
 	if len(s.paths) == 1 {
 		return "file:" + s.paths[0]
@@ -58,49 +84,201 @@ func (s *fileSource) Name() string {
 }
 
 func (s *fileSource) Load(ctx context.Context, schema Schema) (map[string]any, error) {
+	return mergeLayers(s.loadLayers(ctx, schema))
+}
+
+func (s *fileSource) loadLayers(ctx context.Context, schema Schema) ([]sourceLayer, error) {
+	schema = schema.withFS(s.fsys)
 	if len(s.paths) == 0 {
 		if s.optional {
-			return map[string]any{}, nil
+			return []sourceLayer{{name: s.Name(), data: map[string]any{}}}, nil
 		}
 
 		return nil, errors.New("no config paths configured")
 	}
 
-	for _, path := range s.paths {
+	paths, err := s.resolvePaths(schema)
+	if err != nil {
+		return nil, err
+	}
+	var layers []sourceLayer
+	for _, path := range paths {
 		if err := ctx.Err(); err != nil {
 			return nil, err
 		}
 
-		content, err := os.ReadFile(path) //nolint:gosec // path is provided by the caller
-		if err != nil {
-			if os.IsNotExist(err) {
-				continue
-			}
-			return nil, fmt.Errorf("read %s: %w", path, err)
+		name := s.Name()
+		if s.merge {
+			name = "file:" + path
+		}
+		found, err := loadConfigFileLayers(ctx, schema, name, path, s.format)
+		if os.IsNotExist(err) {
+			continue
 		}
+		if err != nil || !s.merge {
+			return found, err
+		}
+		layers = append(layers, found...)
+	}
+	if len(layers) > 0 {
+		return layers, nil
+	}
 
-		configMap, err := parseConfigBytes(path, content)
+	if s.optional {
+		return []sourceLayer{{name: s.Name(), data: map[string]any{}}}, nil
+	}
+
+	return nil, fmt.Errorf("none of the config files exist: %s", strings.Join(s.paths, ", "))
+}
+
+// resolvePaths expands templates, home directories, and glob patterns in the
+// configured paths.
+func (s *fileSource) resolvePaths(schema Schema) ([]string, error) {
+	lookup := withTemplateVariables(schema.lookup, schema.templateVars)
+	if schema.lookup == nil {
+		lookup = os.LookupEnv
+	}
+	var paths []string
+	for _, path := range s.paths {
+		resolved, err := resolvePath(schema, path, lookup)
 		if err != nil {
-			return nil, fmt.Errorf("parse %s: %w", path, err)
+			return nil, err
 		}
+		paths = append(paths, resolved...)
+	}
+	return paths, nil
+}
 
-		return configMap, nil
+// resolvePath resolves one configured path. A path that fails to expand or
+// expands to a missing file is used literally when a file with that exact
+// name exists, and so is a pattern that matches nothing, so names such as
+// app[1].yaml or a${b}.yaml still load.
+func resolvePath(schema Schema, path string, lookup templexp.LookupFunc) ([]string, error) {
+	expanded, err := expandPath(path, lookup, schema.templateOptions)
+	if err != nil {
+		if schema.exists(path) {
+			return []string{path}, nil
+		}
+		return nil, err
 	}
+	if !strings.ContainsAny(expanded, "*?[") {
+		if expanded != path && !schema.exists(expanded) && schema.exists(path) {
+			return []string{path}, nil
+		}
+		return []string{expanded}, nil
+	}
+	matches, err := schema.glob(expanded)
+	if err == nil && len(matches) > 0 {
+		return matches, nil
+	}
+	if schema.exists(expanded) {
+		return []string{expanded}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("match %s: %w", path, err)
+	}
+	return nil, nil
+}
 
-	if s.optional {
-		return map[string]any{}, nil
+// expandPath expands ${VAR} templates and a leading ~ in path.
+func expandPath(path string, lookup templexp.LookupFunc, opts []templexp.Option) (string, error) {
+	expanded, err := templexp.Expand(path, lookup, opts...)
+	if err != nil {
+		return "", fmt.Errorf("expand %s: %w", path, err)
+	}
+	if expanded != "~" && !strings.HasPrefix(expanded, "~/") {
+		return expanded, nil
 	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("expand %s: %w", path, err)
+	}
+	return filepath.Join(home, strings.TrimPrefix(expanded, "~")), nil
+}
 
-	return nil, fmt.Errorf("none of the config files exist: %s", strings.Join(s.paths, ", "))
+// loadConfigFileLayers returns the layers of the config file at path: its
+// includes, its own keys under name, and its profile overlays. A missing file
+// is reported as in readConfigFile.
+func loadConfigFileLayers(ctx context.Context, schema Schema, name, path, format string) ([]sourceLayer, error) {
+	configMap, err := readConfigFile(schema, path, format)
+	if err != nil {
+		return nil, err
+	}
+	sections, err := takeProfileSections(schema, path, configMap)
+	if err != nil {
+		return nil, err
+	}
+	layers, err := loadIncludes(ctx, schema, name, path, configMap, nil)
+	if err != nil {
+		return nil, err
+	}
+	if name != "file:"+path {
+		// Name the file actually read when the source name is a list,
+		// template, or pattern.
+		layers[len(layers)-1].files = []string{path}
+	}
+	overlays, err := loadProfileLayers(ctx, schema, path, format, sections)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(layers, overlays...), nil
+}
+
+// readConfigFile reads and decodes one config file. A missing file is
+// returned as the unwrapped os.ReadFile error so callers can test it with
+// os.IsNotExist.
+func readConfigFile(schema Schema, path, formatName string) (map[string]any, error) {
+	content, err := schema.readFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	format, err := schema.formats.resolve(formatName, path)
+	if err != nil {
+		return nil, err
+	}
+	configMap, err := decodeConfigBytes(format, content)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if schema.documents != nil {
+		schema.documents[path] = sourceDocument{format: format.Name(), content: content}
+	}
+
+	return configMap, nil
 }
 
 type envSource struct {
 	prefix string
+	files  bool
 }
 
 // Env loads environment variables for schema fields using the given prefix.
-func Env(prefix string) Source {
-	return &envSource{prefix: prefix}
+func Env(prefix string, opts ...EnvOption) Source {
+	source := &envSource{prefix: prefix}
+	for _, opt := range opts {
+		opt(source)
+	}
+
+	return source
+}
+
+// EnvOption configures Env sources.
+type EnvOption func(*envSource)
+
+// EnvFiles also reads <NAME>_FILE variables, as used for Docker secrets: the
+// variable holds a file path whose content, minus one trailing newline, is
+// used as the value of <NAME>. Setting both <NAME> and <NAME>_FILE is an
+// error. A _FILE name that is itself the variable of another field keeps its
+// direct meaning.
+func EnvFiles() EnvOption {
+	return func(s *envSource) {
+		s.files = true
+	}
 }
 
 func (s *envSource) Name() string {
@@ -112,18 +290,45 @@ func (s *envSource) Load(ctx context.Context, schema Schema) (map[string]any, er
 		return map[string]any{}, nil
 	}
 
+	lookup := schema.lookup
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+
+	return loadEnvFields(ctx, schema, s.prefix, lookup, s.files)
+}
+
+// loadEnvFields maps prefixed environment-style names onto schema fields.
+// With files set, <NAME>_FILE variables are read as indirections.
+func loadEnvFields(
+	ctx context.Context, schema Schema, prefix string, lookup func(string) (string, bool), files bool,
+) (map[string]any, error) {
+	fields := schema.Fields()
+	direct := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		direct[prefix+envName(field.Path)] = true
+	}
+
 	out := map[string]any{}
-	for _, field := range schema.Fields() {
+	for _, field := range fields {
 		if err := ctx.Err(); err != nil {
 			return nil, err
 		}
 
-		envKey := s.prefix + envName(field.Path)
-		lookup := schema.lookup
-		if lookup == nil {
-			lookup = os.LookupEnv
-		}
+		envKey := prefix + envName(field.Path)
 		value, exists := lookup(envKey)
+		if fileKey := envKey + "_FILE"; files && !direct[fileKey] {
+			if path, ok := lookup(fileKey); ok {
+				if exists {
+					return nil, fmt.Errorf("%s and %s are both set", envKey, fileKey)
+				}
+				content, err := readSecretFile(path)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", fileKey, err)
+				}
+				envKey, value, exists = fileKey, content, true
+			}
+		}
 		if !exists {
 			continue
 		}
@@ -0,0 +1,1009 @@
+package cfgm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// decodeTOML parses a TOML v1.0 document into the generic map shape shared by
+// YAML and JSON files. Tables become map[string]any, arrays become []any,
+// integers become int64, and offset or local date-times become time.Time.
+// Local times have no Go equivalent and are returned as strings.
+func decodeTOML(content []byte) (map[string]any, error) {
+	if !utf8.Valid(content) {
+		return nil, errors.New("toml: document must be valid UTF-8")
+	}
+	p := &tomlParser{
+		text:     string(content),
+		line:     1,
+		root:     map[string]any{},
+		explicit: make(map[string]bool),
+		dotted:   make(map[string]bool),
+		inline:   make(map[string]bool),
+		arrays:   make(map[string]bool),
+	}
+	p.current = p.root
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+
+	return p.root, nil
+}
+
+type tomlParser struct {
+	text        string
+	offset      int
+	line        int
+	root        map[string]any
+	current     map[string]any
+	currentPath string
+	// explicit records tables opened by [header], dotted records tables
+	// created by dotted keys, inline records immutable inline tables and
+	// static arrays, and arrays records arrays of tables.
+	explicit map[string]bool
+	dotted   map[string]bool
+	inline   map[string]bool
+	arrays   map[string]bool
+}
+
+func (p *tomlParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("toml: line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *tomlParser) parse() error {
+	for {
+		p.skipWhitespaceAndComments(true)
+		if p.eof() {
+			return nil
+		}
+		var err error
+		if p.peek() == '[' {
+			err = p.parseTableHeader()
+		} else {
+			err = p.parseKeyValue(p.current, p.currentPath)
+		}
+		if err != nil {
+			return err
+		}
+		if err := p.expectLineEnd(); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *tomlParser) eof() bool { return p.offset >= len(p.text) }
+
+func (p *tomlParser) peek() byte { return p.text[p.offset] }
+
+func (p *tomlParser) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(p.text[p.offset:], prefix)
+}
+
+func (p *tomlParser) skipSpaces() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.offset++
+	}
+}
+
+func (p *tomlParser) skipComment() error {
+	if p.eof() || p.peek() != '#' {
+		return nil
+	}
+	for !p.eof() && p.peek() != '\n' {
+		ch := p.peek()
+		if ch == '\r' && p.hasPrefix("\r\n") {
+			return nil
+		}
+		if isTOMLControl(ch) && ch != '\t' {
+			return p.errorf("control character %U in comment", rune(ch))
+		}
+		p.offset++
+	}
+	return nil
+}
+
+func (p *tomlParser) skipWhitespaceAndComments(newlines bool) {
+	for !p.eof() {
+		switch {
+		case p.peek() == ' ' || p.peek() == '\t':
+			p.offset++
+		case p.peek() == '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.offset++
+			}
+		case newlines && p.peek() == '\n':
+			p.offset++
+			p.line++
+		case newlines && p.hasPrefix("\r\n"):
+			p.offset += 2
+			p.line++
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) expectLineEnd() error {
+	p.skipSpaces()
+	if err := p.skipComment(); err != nil {
+		return err
+	}
+	switch {
+	case p.eof():
+		return nil
+	case p.peek() == '\n':
+		p.offset++
+		p.line++
+		return nil
+	case p.hasPrefix("\r\n"):
+		p.offset += 2
+		p.line++
+		return nil
+	default:
+		return p.errorf("expected end of line, found %q", p.peek())
+	}
+}
+
+func (p *tomlParser) parseTableHeader() error {
+	array := p.hasPrefix("[[")
+	if array {
+		p.offset += 2
+	} else {
+		p.offset++
+	}
+	p.skipSpaces()
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpaces()
+	closing := "]"
+	if array {
+		closing = "]]"
+	}
+	if !p.hasPrefix(closing) {
+		return p.errorf("expected %q after table name", closing)
+	}
+	p.offset += len(closing)
+
+	parent, parentPath, err := p.descend(p.root, "", keys[:len(keys)-1], true)
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	path := tomlPath(parentPath, last)
+	existing, exists := parent[last]
+	if array {
+		if !exists {
+			table := map[string]any{}
+			parent[last] = []any{table}
+			p.arrays[path] = true
+			p.current, p.currentPath = table, tomlIndexPath(path, 0)
+			return nil
+		}
+		items, ok := existing.([]any)
+		if !ok || !p.arrays[path] {
+			return p.errorf("key %q is already defined and is not an array of tables", strings.Join(keys, "."))
+		}
+		table := map[string]any{}
+		parent[last] = append(items, table)
+		p.current, p.currentPath = table, tomlIndexPath(path, len(items))
+		return nil
+	}
+	if !exists {
+		table := map[string]any{}
+		parent[last] = table
+		p.explicit[path] = true
+		p.current, p.currentPath = table, path
+		return nil
+	}
+	table, ok := existing.(map[string]any)
+	if !ok || p.explicit[path] || p.dotted[path] || p.inline[path] {
+		return p.errorf("table %q is already defined", strings.Join(keys, "."))
+	}
+	p.explicit[path] = true
+	p.current, p.currentPath = table, path
+	return nil
+}
+
+// descend walks keys below table, creating intermediate tables. Header
+// lookups enter the latest element of arrays of tables; dotted key lookups
+// mark created tables so later headers cannot redefine them.
+func (p *tomlParser) descend(table map[string]any, path string, keys []string, header bool) (map[string]any, string, error) {
+	for _, key := range keys {
+		path = tomlPath(path, key)
+		existing, exists := table[key]
+		if !exists {
+			next := map[string]any{}
+			table[key] = next
+			if !header {
+				p.dotted[path] = true
+			}
+			table = next
+			continue
+		}
+		switch typed := existing.(type) {
+		case map[string]any:
+			if p.inline[path] {
+				return nil, "", p.errorf("inline table %q cannot be extended", key)
+			}
+			if !header && p.explicit[path] {
+				return nil, "", p.errorf("table %q is already defined", key)
+			}
+			table = typed
+		case []any:
+			if !header || !p.arrays[path] {
+				return nil, "", p.errorf("key %q is already defined as an array", key)
+			}
+			last, ok := typed[len(typed)-1].(map[string]any)
+			if !ok {
+				return nil, "", p.errorf("key %q is not an array of tables", key)
+			}
+			path = tomlIndexPath(path, len(typed)-1)
+			table = last
+		default:
+			return nil, "", p.errorf("key %q is already defined as a value", key)
+		}
+	}
+	return table, path, nil
+}
+
+func (p *tomlParser) parseKeyValue(table map[string]any, path string) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpaces()
+	if p.eof() || p.peek() != '=' {
+		return p.errorf("expected '=' after key %q", strings.Join(keys, "."))
+	}
+	p.offset++
+	p.skipSpaces()
+
+	parent, parentPath, err := p.descend(table, path, keys[:len(keys)-1], false)
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	if _, exists := parent[last]; exists {
+		return p.errorf("key %q is already defined", strings.Join(keys, "."))
+	}
+	valuePath := tomlPath(parentPath, last)
+	value, err := p.parseValue(valuePath)
+	if err != nil {
+		return err
+	}
+	parent[last] = value
+	return nil
+}
+
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpaces()
+		if p.eof() {
+			return nil, p.errorf("expected key")
+		}
+		var key string
+		var err error
+		switch p.peek() {
+		case '"':
+			if p.hasPrefix(`"""`) {
+				return nil, p.errorf("multi-line strings cannot be keys")
+			}
+			key, err = p.parseBasicString()
+		case '\'':
+			if p.hasPrefix("'''") {
+				return nil, p.errorf("multi-line strings cannot be keys")
+			}
+			key, err = p.parseLiteralString()
+		default:
+			start := p.offset
+			for !p.eof() && isTOMLBareKeyChar(p.peek()) {
+				p.offset++
+			}
+			if start == p.offset {
+				return nil, p.errorf("invalid key character %q", p.peek())
+			}
+			key = p.text[start:p.offset]
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		p.skipSpaces()
+		if p.eof() || p.peek() != '.' {
+			return keys, nil
+		}
+		p.offset++
+	}
+}
+
+func (p *tomlParser) parseValue(path string) (any, error) {
+	if p.eof() {
+		return nil, p.errorf("expected value")
+	}
+	switch ch := p.peek(); {
+	case p.hasPrefix(`"""`):
+		return p.parseMultilineBasicString()
+	case ch == '"':
+		return p.parseBasicString()
+	case p.hasPrefix("'''"):
+		return p.parseMultilineLiteralString()
+	case ch == '\'':
+		return p.parseLiteralString()
+	case ch == '[':
+		p.inline[path] = true
+		return p.parseArray(path)
+	case ch == '{':
+		p.inline[path] = true
+		return p.parseInlineTable(path)
+	case p.hasPrefix("true") && p.boundaryAfter(4):
+		p.offset += 4
+		return true, nil
+	case p.hasPrefix("false") && p.boundaryAfter(5):
+		p.offset += 5
+		return false, nil
+	default:
+		return p.parseNumberOrDate()
+	}
+}
+
+func (p *tomlParser) boundaryAfter(width int) bool {
+	if p.offset+width >= len(p.text) {
+		return true
+	}
+	return !isTOMLBareKeyChar(p.text[p.offset+width])
+}
+
+func (p *tomlParser) parseArray(path string) ([]any, error) {
+	p.offset++
+	items := []any{}
+	for {
+		p.skipWhitespaceAndComments(true)
+		if p.eof() {
+			return nil, p.errorf("unterminated array")
+		}
+		if p.peek() == ']' {
+			p.offset++
+			return items, nil
+		}
+		value, err := p.parseValue(tomlIndexPath(path, len(items)))
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, value)
+		p.skipWhitespaceAndComments(true)
+		if p.eof() {
+			return nil, p.errorf("unterminated array")
+		}
+		switch p.peek() {
+		case ',':
+			p.offset++
+		case ']':
+			p.offset++
+			return items, nil
+		default:
+			return nil, p.errorf("expected ',' or ']' in array, found %q", p.peek())
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable(path string) (map[string]any, error) {
+	p.offset++
+	table := map[string]any{}
+	p.skipSpaces()
+	if !p.eof() && p.peek() == '}' {
+		p.offset++
+		return table, nil
+	}
+	for {
+		p.skipSpaces()
+		if err := p.parseKeyValue(table, path); err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		if p.eof() {
+			return nil, p.errorf("unterminated inline table")
+		}
+		switch p.peek() {
+		case ',':
+			p.offset++
+		case '}':
+			p.offset++
+			return table, nil
+		default:
+			return nil, p.errorf("expected ',' or '}' in inline table, found %q", p.peek())
+		}
+	}
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.offset++
+	var out strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated string")
+		}
+		ch := p.peek()
+		switch {
+		case ch == '"':
+			p.offset++
+			return out.String(), nil
+		case ch == '\\':
+			if err := p.parseEscape(&out); err != nil {
+				return "", err
+			}
+		case ch == '\n' || ch == '\r':
+			return "", p.errorf("newline in single-line string")
+		case isTOMLControl(ch) && ch != '\t':
+			return "", p.errorf("control character %U in string", rune(ch))
+		default:
+			out.WriteByte(ch)
+			p.offset++
+		}
+	}
+}
+
+func (p *tomlParser) parseMultilineBasicString() (string, error) {
+	p.offset += 3
+	p.skipLeadingNewline()
+	var out strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated multi-line string")
+		}
+		if p.hasPrefix(`"""`) {
+			// Up to two quotes may directly precede the closing delimiter.
+			extra := 0
+			for extra < 2 && p.offset+3+extra < len(p.text) && p.text[p.offset+3+extra] == '"' {
+				extra++
+			}
+			out.WriteString(strings.Repeat(`"`, extra))
+			p.offset += 3 + extra
+			return out.String(), nil
+		}
+		ch := p.peek()
+		switch {
+		case ch == '\\':
+			if p.lineEndingBackslash() {
+				continue
+			}
+			if err := p.parseEscape(&out); err != nil {
+				return "", err
+			}
+		case ch == '\n':
+			out.WriteByte('\n')
+			p.offset++
+			p.line++
+		case p.hasPrefix("\r\n"):
+			out.WriteByte('\n')
+			p.offset += 2
+			p.line++
+		case isTOMLControl(ch) && ch != '\t':
+			return "", p.errorf("control character %U in string", rune(ch))
+		default:
+			out.WriteByte(ch)
+			p.offset++
+		}
+	}
+}
+
+// lineEndingBackslash consumes a backslash followed by optional spaces and a
+// newline, together with all whitespace up to the next non-blank character.
+func (p *tomlParser) lineEndingBackslash() bool {
+	index := p.offset + 1
+	for index < len(p.text) && (p.text[index] == ' ' || p.text[index] == '\t') {
+		index++
+	}
+	if index >= len(p.text) || (p.text[index] != '\n' && !strings.HasPrefix(p.text[index:], "\r\n")) {
+		return false
+	}
+	p.offset = index
+	for !p.eof() {
+		switch {
+		case p.peek() == ' ' || p.peek() == '\t':
+			p.offset++
+		case p.peek() == '\n':
+			p.offset++
+			p.line++
+		case p.hasPrefix("\r\n"):
+			p.offset += 2
+			p.line++
+		default:
+			return true
+		}
+	}
+	return true
+}
+
+func (p *tomlParser) parseEscape(out *strings.Builder) error {
+	if p.offset+1 >= len(p.text) {
+		return p.errorf("unterminated escape sequence")
+	}
+	escape := p.text[p.offset+1]
+	p.offset += 2
+	switch escape {
+	case 'b':
+		out.WriteByte('\b')
+	case 't':
+		out.WriteByte('\t')
+	case 'n':
+		out.WriteByte('\n')
+	case 'f':
+		out.WriteByte('\f')
+	case 'r':
+		out.WriteByte('\r')
+	case '"':
+		out.WriteByte('"')
+	case '\\':
+		out.WriteByte('\\')
+	case 'u', 'U':
+		width := 4
+		if escape == 'U' {
+			width = 8
+		}
+		if p.offset+width > len(p.text) {
+			return p.errorf("invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(p.text[p.offset:p.offset+width], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return p.errorf("invalid unicode escape %q", p.text[p.offset-2:p.offset+width])
+		}
+		out.WriteRune(rune(code))
+		p.offset += width
+	default:
+		return p.errorf("invalid escape sequence \\%c", escape)
+	}
+	return nil
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.offset++
+	start := p.offset
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated string")
+		}
+		ch := p.peek()
+		switch {
+		case ch == '\'':
+			value := p.text[start:p.offset]
+			p.offset++
+			return value, nil
+		case ch == '\n' || ch == '\r':
+			return "", p.errorf("newline in single-line string")
+		case isTOMLControl(ch) && ch != '\t':
+			return "", p.errorf("control character %U in string", rune(ch))
+		}
+		p.offset++
+	}
+}
+
+func (p *tomlParser) parseMultilineLiteralString() (string, error) {
+	p.offset += 3
+	p.skipLeadingNewline()
+	var out strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated multi-line string")
+		}
+		if p.hasPrefix("'''") {
+			extra := 0
+			for extra < 2 && p.offset+3+extra < len(p.text) && p.text[p.offset+3+extra] == '\'' {
+				extra++
+			}
+			out.WriteString(strings.Repeat("'", extra))
+			p.offset += 3 + extra
+			return out.String(), nil
+		}
+		ch := p.peek()
+		switch {
+		case ch == '\n':
+			out.WriteByte('\n')
+			p.offset++
+			p.line++
+		case p.hasPrefix("\r\n"):
+			out.WriteByte('\n')
+			p.offset += 2
+			p.line++
+		case isTOMLControl(ch) && ch != '\t':
+			return "", p.errorf("control character %U in string", rune(ch))
+		default:
+			out.WriteByte(ch)
+			p.offset++
+		}
+	}
+}
+
+func (p *tomlParser) skipLeadingNewline() {
+	if p.hasPrefix("\n") {
+		p.offset++
+		p.line++
+	} else if p.hasPrefix("\r\n") {
+		p.offset += 2
+		p.line++
+	}
+}
+
+func (p *tomlParser) parseNumberOrDate() (any, error) {
+	start := p.offset
+	for !p.eof() {
+		ch := p.peek()
+		// A single space may separate the date and time of a date-time.
+		if ch == ' ' && p.offset-start == 10 && p.offset+1 < len(p.text) && isDigit(p.text[p.offset+1]) {
+			p.offset++
+			continue
+		}
+		if !isTOMLBareKeyChar(ch) && ch != '.' && ch != ':' && ch != '+' {
+			break
+		}
+		p.offset++
+	}
+	token := p.text[start:p.offset]
+	if token == "" {
+		return nil, p.errorf("expected value, found %q", p.peek())
+	}
+	if value, ok := parseTOMLDateTime(token); ok {
+		return value, nil
+	}
+	if value, ok := parseTOMLInteger(token); ok {
+		return value, nil
+	}
+	if value, ok := parseTOMLFloat(token); ok {
+		return value, nil
+	}
+	return nil, p.errorf("invalid value %q", token)
+}
+
+func parseTOMLInteger(token string) (int64, bool) {
+	base := 10
+	digits := token
+	switch {
+	case strings.HasPrefix(token, "0x"):
+		base, digits = 16, token[2:]
+	case strings.HasPrefix(token, "0o"):
+		base, digits = 8, token[2:]
+	case strings.HasPrefix(token, "0b"):
+		base, digits = 2, token[2:]
+	default:
+		unsigned := strings.TrimLeft(token, "+-")
+		if len(unsigned) > 1 && unsigned[0] == '0' {
+			return 0, false
+		}
+	}
+	if !validTOMLUnderscores(strings.TrimLeft(digits, "+-")) {
+		return 0, false
+	}
+	if base != 10 && strings.ContainsAny(digits, "+-") {
+		return 0, false
+	}
+	value, err := strconv.ParseInt(strings.ReplaceAll(digits, "_", ""), base, 64)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
+func parseTOMLFloat(token string) (float64, bool) {
+	switch strings.TrimLeft(token, "+-") {
+	case "inf":
+		if strings.HasPrefix(token, "-") {
+			return math.Inf(-1), true
+		}
+		return math.Inf(1), true
+	case "nan":
+		return math.NaN(), true
+	}
+	unsigned := strings.TrimLeft(token, "+-")
+	if unsigned == "" || !isDigit(unsigned[0]) {
+		return 0, false
+	}
+	mantissa, _, _ := strings.Cut(strings.ToLower(unsigned), "e")
+	integer, fraction, hasFraction := strings.Cut(mantissa, ".")
+	if len(integer) > 1 && integer[0] == '0' {
+		return 0, false
+	}
+	if hasFraction && (fraction == "" || !isDigit(fraction[0])) {
+		return 0, false
+	}
+	if !validTOMLUnderscores(unsigned) || strings.Contains(unsigned, "_.") || strings.Contains(unsigned, "._") {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(strings.ReplaceAll(token, "_", ""), 64)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
+func validTOMLUnderscores(digits string) bool {
+	if digits == "" || digits[0] == '_' || digits[len(digits)-1] == '_' {
+		return false
+	}
+	return !strings.Contains(digits, "__")
+}
+
+var tomlDateTimeLayouts = []struct {
+	layout string
+	local  bool
+}{
+	{layout: "2006-01-02T15:04:05.999999999Z07:00"},
+	{layout: "2006-01-02T15:04:05.999999999", local: true},
+	{layout: "2006-01-02", local: true},
+}
+
+func parseTOMLDateTime(token string) (any, bool) {
+	if len(token) >= 10 && token[4] == '-' && token[7] == '-' {
+		normalized := strings.ToUpper(token)
+		if len(normalized) > 10 && (normalized[10] == ' ' || normalized[10] == 'T') {
+			normalized = normalized[:10] + "T" + normalized[11:]
+		}
+		for _, candidate := range tomlDateTimeLayouts {
+			var value time.Time
+			var err error
+			if candidate.local {
+				value, err = time.ParseInLocation(candidate.layout, normalized, time.Local)
+			} else {
+				value, err = time.Parse(candidate.layout, normalized)
+			}
+			if err == nil {
+				return value, true
+			}
+		}
+		return nil, false
+	}
+	if len(token) >= 8 && token[2] == ':' && token[5] == ':' {
+		if _, err := time.Parse("15:04:05.999999999", token); err == nil {
+			return token, true
+		}
+	}
+	return nil, false
+}
+
+func isTOMLBareKeyChar(ch byte) bool {
+	return ch == '_' || ch == '-' || isDigit(ch) || ch >= 'A' && ch <= 'Z' || ch >= 'a' && ch <= 'z'
+}
+
+func isTOMLControl(ch byte) bool {
+	return ch < 0x20 || ch == 0x7f
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func tomlPath(parent, key string) string {
+	return parent + "\x1f" + key
+}
+
+func tomlIndexPath(path string, index int) string {
+	return path + "\x1e" + strconv.Itoa(index)
+}
+
+// encodeTOML writes data as a TOML document with sorted keys.
+func encodeTOML(data map[string]any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeTOMLTable(&buf, nil, mapToOrderedFields(data)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeTOMLTable(buf *bytes.Buffer, path []string, fields []orderedField) error {
+	for _, field := range fields {
+		if field.value == nil || isTOMLTable(field.value) || isTOMLTableArray(field.value) {
+			continue
+		}
+		text, err := tomlInlineValue(field.value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", strings.Join(append(path, field.key), "."), err)
+		}
+		line := tomlKey(field.key) + " = " + text
+		switch {
+		case strings.Contains(field.comment, "\n"):
+			buf.WriteString("\n")
+			writeTOMLComment(buf, field.comment)
+			buf.WriteString(line + "\n")
+		case field.comment != "":
+			buf.WriteString(line + " # " + field.comment + "\n")
+		default:
+			buf.WriteString(line + "\n")
+		}
+	}
+	for _, field := range fields {
+		switch {
+		case isTOMLTable(field.value):
+			childPath := append(append([]string(nil), path...), field.key)
+			writeTOMLHeader(buf, "["+tomlHeaderPath(childPath)+"]", field.comment)
+			if err := writeTOMLTable(buf, childPath, field.value.([]orderedField)); err != nil { //nolint:forcetypeassert // checked by isTOMLTable
+				return err
+			}
+		case isTOMLTableArray(field.value):
+			childPath := append(append([]string(nil), path...), field.key)
+			for index, item := range field.value.([]any) { //nolint:forcetypeassert // checked by isTOMLTableArray
+				comment := ""
+				if index == 0 {
+					comment = field.comment
+				}
+				writeTOMLHeader(buf, "[["+tomlHeaderPath(childPath)+"]]", comment)
+				if err := writeTOMLTable(buf, childPath, item.([]orderedField)); err != nil { //nolint:forcetypeassert // checked by isTOMLTableArray
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func writeTOMLHeader(buf *bytes.Buffer, header, comment string) {
+	if buf.Len() > 0 {
+		buf.WriteString("\n")
+	}
+	writeTOMLComment(buf, comment)
+	buf.WriteString(header + "\n")
+}
+
+func writeTOMLComment(buf *bytes.Buffer, comment string) {
+	if comment == "" {
+		return
+	}
+	for line := range strings.SplitSeq(comment, "\n") {
+		if line == "" {
+			buf.WriteString("#\n")
+			continue
+		}
+		buf.WriteString("# " + line + "\n")
+	}
+}
+
+// isTOMLTable reports whether value is written as a [table] section. Empty
+// tables are written inline as {} next to the scalar keys.
+func isTOMLTable(value any) bool {
+	fields, ok := value.([]orderedField)
+	return ok && len(fields) > 0
+}
+
+func isTOMLTableArray(value any) bool {
+	items, ok := value.([]any)
+	if !ok || len(items) == 0 {
+		return false
+	}
+	for _, item := range items {
+		if _, ok := item.([]orderedField); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func tomlInlineValue(value any) (string, error) {
+	switch typed := value.(type) {
+	case nil:
+		return "", errors.New("TOML cannot represent null inside arrays or inline tables")
+	case string:
+		return tomlQuote(typed), nil
+	case bool:
+		return strconv.FormatBool(typed), nil
+	case time.Time:
+		return typed.Format(time.RFC3339Nano), nil
+	case time.Duration:
+		return tomlQuote(typed.String()), nil
+	case []orderedField:
+		parts := make([]string, 0, len(typed))
+		for _, field := range typed {
+			if field.value == nil {
+				continue
+			}
+			text, err := tomlInlineValue(field.value)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, tomlKey(field.key)+" = "+text)
+		}
+		if len(parts) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(parts, ", ") + " }", nil
+	case []any:
+		parts := make([]string, len(typed))
+		for index, item := range typed {
+			text, err := tomlInlineValue(item)
+			if err != nil {
+				return "", err
+			}
+			parts[index] = text
+		}
+		return "[" + strings.Join(parts, ", ") + "]", nil
+	}
+
+	val := reflect.ValueOf(value)
+	switch val.Kind() { //nolint:exhaustive // remaining kinds are formatted as strings
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(val.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(val.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return tomlFloat(val.Float()), nil
+	case reflect.String:
+		return tomlQuote(val.String()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(val.Bool()), nil
+	default:
+		return tomlQuote(fmt.Sprintf("%v", value)), nil
+	}
+}
+
+func tomlFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "inf"
+	case math.IsInf(value, -1):
+		return "-inf"
+	case math.IsNaN(value):
+		return "nan"
+	}
+	text := strconv.FormatFloat(value, 'g', -1, 64)
+	if !strings.ContainsAny(text, ".eEn") {
+		text += ".0"
+	}
+	return text
+}
+
+func tomlQuote(value string) string {
+	var out strings.Builder
+	out.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '"':
+			out.WriteString(`\"`)
+		case '\\':
+			out.WriteString(`\\`)
+		case '\b':
+			out.WriteString(`\b`)
+		case '\t':
+			out.WriteString(`\t`)
+		case '\n':
+			out.WriteString(`\n`)
+		case '\f':
+			out.WriteString(`\f`)
+		case '\r':
+			out.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&out, `\u%04X`, r)
+				continue
+			}
+			out.WriteRune(r)
+		}
+	}
+	out.WriteByte('"')
+	return out.String()
+}
+
+func tomlKey(key string) string {
+	if key == "" {
+		return `""`
+	}
+	for index := range len(key) {
+		if !isTOMLBareKeyChar(key[index]) {
+			return tomlQuote(key)
+		}
+	}
+	return key
+}
+
+func tomlHeaderPath(path []string) string {
+	keys := make([]string, len(path))
+	for index, key := range path {
+		keys[index] = tomlKey(key)
+	}
+	return strings.Join(keys, ".")
+}
@@ -0,0 +1,222 @@
+package cfgm
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeTOML(t *testing.T) {
+	data, err := decodeTOML([]byte(`# comment
+title = "TOML \"quoted\" \u00e9"
+literal = 'C:\path'
+multiline = """
+first \
+  second"""
+raw = '''
+line1
+line2'''
+int = +1_000
+hex = 0xff
+octal = 0o17
+binary = 0b101
+float = 6.626e-34
+infinite = -inf
+enabled = true
+offset = 1979-05-27T07:32:00Z
+local-date = 1979-05-27
+local-time = 07:32:00
+"quoted key" = 1
+site."google.com" = true
+
+[server]
+addr = ":8080" # trailing comment
+ports = [
+  8001,
+  8002, # trailing comma
+]
+inline = { name = "x", nested.value = 1 }
+
+[server.redis]
+url = "redis://localhost"
+
+[[routes]]
+path = "/a"
+
+[[routes]]
+path = "/b"
+[[routes.backends]]
+url = "http://b"
+`))
+	require.NoError(t, err)
+	assert.Equal(t, `TOML "quoted" é`, data["title"])
+	assert.Equal(t, `C:\path`, data["literal"])
+	assert.Equal(t, "first second", data["multiline"])
+	assert.Equal(t, "line1\nline2", data["raw"])
+	assert.Equal(t, int64(1000), data["int"])
+	assert.Equal(t, int64(255), data["hex"])
+	assert.Equal(t, int64(15), data["octal"])
+	assert.Equal(t, int64(5), data["binary"])
+	assert.InDelta(t, 6.626e-34, data["float"], 1e-40)
+	assert.True(t, math.IsInf(data["infinite"].(float64), -1))
+	assert.Equal(t, true, data["enabled"])
+	assert.Equal(t, time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC), data["offset"])
+	assert.Equal(t, time.Date(1979, 5, 27, 0, 0, 0, 0, time.Local), data["local-date"])
+	assert.Equal(t, "07:32:00", data["local-time"])
+	assert.Equal(t, int64(1), data["quoted key"])
+	assert.Equal(t, map[string]any{"google.com": true}, data["site"])
+	assert.Equal(t, map[string]any{
+		"addr":   ":8080",
+		"ports":  []any{int64(8001), int64(8002)},
+		"inline": map[string]any{"name": "x", "nested": map[string]any{"value": int64(1)}},
+		"redis":  map[string]any{"url": "redis://localhost"},
+	}, data["server"])
+	assert.Equal(t, []any{
+		map[string]any{"path": "/a"},
+		map[string]any{"path": "/b", "backends": []any{map[string]any{"url": "http://b"}}},
+	}, data["routes"])
+}
+
+func TestDecodeTOMLRejectsInvalidDocuments(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		message string
+	}{
+		{name: "duplicate key", content: "a = 1\na = 2\n", message: "line 2: key \"a\" is already defined"},
+		{name: "duplicate table", content: "[a]\n[a]\n", message: "table \"a\" is already defined"},
+		{name: "value as table", content: "a = 1\n[a]\n", message: "already defined"},
+		{name: "extend inline table", content: "a = {}\n[a.b]\n", message: "inline table"},
+		{name: "static array as table array", content: "a = []\n[[a]]\n", message: "not an array of tables"},
+		{name: "missing value", content: "a =\n", message: "expected value"},
+		{name: "bad escape", content: `a = "\x"` + "\n", message: "invalid escape"},
+		{name: "leading zero", content: "a = 01\n", message: "invalid value"},
+		{name: "trailing content", content: "a = 1 b = 2\n", message: "expected end of line"},
+		{name: "unterminated array", content: "a = [1,\n", message: "unterminated array"},
+		{name: "newline in string", content: "a = \"x\ny\"\n", message: "newline in single-line string"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := decodeTOML([]byte(test.content))
+			require.ErrorContains(t, err, test.message)
+		})
+	}
+}
+
+func TestEncodeTOMLRoundTrips(t *testing.T) {
+	data := map[string]any{
+		"name":    "app\n\"quoted\"",
+		"weird":   map[string]any{"dotted.key": "v"},
+		"enabled": true,
+		"ratio":   float64(2),
+		"ports":   []any{int64(1), int64(2)},
+		"empty":   map[string]any{},
+		"server":  map[string]any{"addr": ":8080", "redis": map[string]any{"url": "redis://"}},
+		"routes":  []any{map[string]any{"path": "/a"}, map[string]any{"path": "/b"}},
+	}
+	encoded, err := encodeTOML(data)
+	require.NoError(t, err)
+	decoded, err := decodeTOML(encoded)
+	require.NoError(t, err, string(encoded))
+	assert.Equal(t, map[string]any{
+		"name":    "app\n\"quoted\"",
+		"weird":   map[string]any{"dotted.key": "v"},
+		"enabled": true,
+		"ratio":   float64(2),
+		"ports":   []any{int64(1), int64(2)},
+		"empty":   map[string]any{},
+		"server":  map[string]any{"addr": ":8080", "redis": map[string]any{"url": "redis://"}},
+		"routes":  []any{map[string]any{"path": "/a"}, map[string]any{"path": "/b"}},
+	}, decoded)
+}
+
+func TestManagerLoadsTOMLFiles(t *testing.T) {
+	type Route struct {
+		Path    string        `json:"path"`
+		Timeout time.Duration `json:"timeout"`
+	}
+	type Config struct {
+		Name    string            `json:"name"`
+		Workers uint16            `json:"workers"`
+		Labels  map[string]string `json:"labels"`
+		Routes  []Route           `json:"routes"`
+		Started time.Time         `json:"started"`
+	}
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`name = "from-toml"
+workers = 4
+started = 2026-07-15T10:20:30Z
+
+[labels]
+region = "cn"
+
+[[routes]]
+path = "/api"
+timeout = "5s"
+`), 0o600))
+
+	cfg, err := New(Config{}, WithoutDefaultPaths()).Load(t.Context(), File(path))
+	require.NoError(t, err)
+	assert.Equal(t, "from-toml", cfg.Name)
+	assert.Equal(t, uint16(4), cfg.Workers)
+	assert.Equal(t, map[string]string{"region": "cn"}, cfg.Labels)
+	assert.Equal(t, []Route{{Path: "/api", Timeout: 5 * time.Second}}, cfg.Routes)
+	assert.Equal(t, time.Date(2026, 7, 15, 10, 20, 30, 0, time.UTC), cfg.Started)
+
+	require.NoError(t, os.WriteFile(path, []byte("name = \"x\"\n[typo]\nkey = 1\n"), 0o600))
+	_, err = New(Config{}, WithoutDefaultPaths()).Load(t.Context(), File(path))
+	require.ErrorContains(t, err, "unknown config keys")
+	require.ErrorContains(t, err, "- typo")
+}
+
+func TestExampleTOMLLoadsBackIntoDefaults(t *testing.T) {
+	type Provider struct {
+		Issuer string `json:"issuer"`
+	}
+	type Config struct {
+		Name      string            `json:"name"      desc:"Name\nspans lines"`
+		Timeout   time.Duration     `json:"timeout"`
+		Tags      []string          `json:"tags"`
+		Labels    map[string]string `json:"labels"    desc:"Labels"`
+		Empty     map[string]string `json:"empty"`
+		Provider  *Provider         `json:"provider"`
+		Providers []Provider        `json:"providers" desc:"Providers"`
+	}
+	defaults := Config{
+		Name:      "app",
+		Timeout:   time.Minute,
+		Labels:    map[string]string{"region": "cn"},
+		Providers: []Provider{{Issuer: "a"}, {Issuer: "b"}},
+	}
+	example := ExampleTOML(defaults)
+	assert.Contains(t, string(example), "# Name\n# spans lines\nname = \"app\"\n")
+	assert.Contains(t, string(example), "# Labels\n[labels]\nregion = \"cn\"\n")
+	assert.Contains(t, string(example), "# Providers\n[[providers]]\nissuer = \"a\"\n\n[[providers]]\nissuer = \"b\"\n")
+	assert.NotContains(t, string(example), "provider =")
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, example, 0o600))
+	cfg, err := New(Config{}, WithoutDefaultPaths()).Load(t.Context(), File(path))
+	require.NoError(t, err)
+	assert.Equal(t, defaults.Name, cfg.Name)
+	assert.Equal(t, defaults.Timeout, cfg.Timeout)
+	assert.Equal(t, defaults.Labels, cfg.Labels)
+	assert.Equal(t, defaults.Providers, cfg.Providers)
+	assert.Nil(t, cfg.Provider)
+}
+
+func TestInitConfigFileWritesTOML(t *testing.T) {
+	type Config struct {
+		Name string `json:"name"`
+	}
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, InitConfigFile(Config{Name: "app"}, path))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "name = \"app\"\n", string(content))
+}
@@ -3,10 +3,23 @@
 //
 // The syntax follows the interpolation subset used by Docker Compose. It
 // supports ${VAR}, default values, alternate values, required values, nested
-// interpolation in words, and $$ escaping. It does not execute commands or
-// support assignment operators.
+// interpolation in words, and $$ escaping, plus the shell pattern removal,
+// replacement, substring, length, and case operators described in [Expand].
+// It does not execute commands or support assignment operators.
 //
 // Callers provide a [LookupFunc], so interpolation is independent of process
 // environment state. Pass os.LookupEnv when environment variables are the
 // desired source.
+//
+// Namespaced references such as ${file:/run/secrets/db} resolve through a
+// [Resolver] registered with [WithResolver]. [FileResolver], [EnvResolver],
+// and [Base64Resolver] cover common cases; no resolver is enabled by default.
+//
+// [Parse] returns a [Template] for static analysis without evaluating it:
+// [Template.Vars] lists every referenced variable with its operator, and
+// [Template.Walk] visits the references, so tools can report which variables
+// a configuration needs before it is loaded.
+//
+// [ExpandReader] expands a stream incrementally. The templexp command in
+// cmd/templexp wraps it for shell scripts.
 package templexp
@@ -35,3 +35,15 @@ func ExampleExpand_requiredValue() {
 	// Output:
 	// templexp: API_KEY: API_KEY is required
 }
+
+func ExampleTemplate_Vars() {
+	tmpl, _ := templexp.Parse(`postgres://${DB_USER:-app}:${DB_PASSWORD:?}@${DB_HOST}/app`)
+	for _, v := range tmpl.Vars() {
+		fmt.Printf("%s default=%t required=%t\n", v.Name, v.HasDefault, v.Required)
+	}
+
+	// Output:
+	// DB_USER default=true required=false
+	// DB_PASSWORD default=false required=true
+	// DB_HOST default=false required=false
+}
@@ -0,0 +1,168 @@
+package templexp
+
+import (
+	"errors"
+)
+
+// Template is a parsed template. It is immutable and safe for concurrent use.
+type Template struct {
+	text  string
+	parts []Part
+}
+
+// Part is a literal run of text or a reference. Literal holds unescaped text,
+// so $$ appears as $.
+type Part struct {
+	Literal string
+	Ref     *Ref
+}
+
+// Ref is a ${...} reference.
+type Ref struct {
+	// Namespace is the resolver namespace of ${ns:argument}, or "" for a
+	// plain variable.
+	Namespace string
+	// Name is the variable name, or the resolver argument.
+	Name string
+	Op   Operator
+	// Word is the operand of Op, which may contain nested references. For
+	// the pattern operators it is the pattern.
+	Word []Part
+	// Replacement is the replacement of the / and // operators.
+	Replacement []Part
+	// Start and Length are the bounds of OpSubstring. HasLength reports
+	// whether a length was given.
+	Start     int
+	Length    int
+	HasLength bool
+	// Offset is the byte offset of the opening "${".
+	Offset int
+
+	namespaceOffset int
+	wordOffset      int
+}
+
+// Key returns the reference as ns:argument, or the variable name.
+func (r *Ref) Key() string {
+	if r.Namespace == "" {
+		return r.Name
+	}
+	return r.Namespace + ":" + r.Name
+}
+
+// Var describes one reference found by Template.Vars.
+type Var struct {
+	Namespace string
+	Name      string
+	Op        Operator
+	// HasDefault reports a - or :- operator, so the variable may be unset.
+	HasDefault bool
+	// Required reports a ? or :? operator.
+	Required bool
+	Offset   int
+}
+
+// Parse parses text without evaluating it. References to any resolver
+// namespace are accepted; Expand reports namespaces without a resolver.
+func Parse(text string) (*Template, error) {
+	p := parser{text: text}
+	parts, _, err := p.parse("", 0)
+	if err != nil {
+		return nil, locateError(err, text)
+	}
+	return &Template{text: text, parts: parts}, nil
+}
+
+// Execute evaluates the template with lookup and the resolvers in opts, as
+// Expand does. It may be called concurrently; each call resolves every
+// variable at most once.
+func (t *Template) Execute(lookup LookupFunc, opts ...Option) (string, error) {
+	if lookup == nil {
+		return "", errors.New("templexp: nil lookup function")
+	}
+	o := newOptions(opts)
+	if err := checkNamespaces(t, o.resolvers); err != nil {
+		return "", locateError(err, t.text)
+	}
+
+	e := evaluator{
+		lookup: lookup, resolvers: o.resolvers, strict: o.strict, allowed: o.allowed,
+		strictNamespaces: o.strictNamespaces, cache: make(map[string]resolvedValue),
+	}
+
+	result, err := e.expand(t.parts)
+	if err != nil {
+		return "", locateError(err, t.text)
+	}
+	return result, nil
+}
+
+// String returns the source text of the template.
+func (t *Template) String() string {
+	return t.text
+}
+
+// Parts returns a copy of the top-level parts of the template in source
+// order. Changing the copy does not affect the template.
+func (t *Template) Parts() []Part {
+	return cloneParts(t.parts)
+}
+
+// Walk calls visit for every reference in source order, visiting a
+// reference before the references nested in its word. When visit returns
+// false the word and replacement of that reference are skipped. Each ref is
+// a copy, so changing it does not affect the template.
+func (t *Template) Walk(visit func(ref *Ref) bool) {
+	walkParts(t.parts, func(ref *Ref) bool {
+		return visit(ref.clone())
+	})
+}
+
+func walkParts(parts []Part, visit func(ref *Ref) bool) {
+	for _, part := range parts {
+		if part.Ref != nil && visit(part.Ref) {
+			walkParts(part.Ref.Word, visit)
+			walkParts(part.Ref.Replacement, visit)
+		}
+	}
+}
+
+func cloneParts(parts []Part) []Part {
+	if parts == nil {
+		return nil
+	}
+	out := make([]Part, len(parts))
+	for index, part := range parts {
+		out[index] = part
+		if part.Ref != nil {
+			out[index].Ref = part.Ref.clone()
+		}
+	}
+	return out
+}
+
+func (r *Ref) clone() *Ref {
+	out := *r
+	out.Word = cloneParts(r.Word)
+	out.Replacement = cloneParts(r.Replacement)
+	return &out
+}
+
+// Vars lists every reference in source order, including references nested
+// in words, which are only evaluated when their operator needs them. A
+// variable referenced twice is listed twice.
+func (t *Template) Vars() []Var {
+	var vars []Var
+	walkParts(t.parts, func(ref *Ref) bool {
+		vars = append(vars, Var{
+			Namespace:  ref.Namespace,
+			Name:       ref.Name,
+			Op:         ref.Op,
+			HasDefault: ref.Op == OpDefaultIfUnset || ref.Op == OpDefaultIfEmpty,
+			Required:   ref.Op == OpRequiredIfUnset || ref.Op == OpRequiredIfEmpty,
+			Offset:     ref.Offset,
+		})
+		return true
+	})
+	return vars
+}
@@ -0,0 +1,190 @@
+package templexp_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lwmacct/251207-go-pkg-cfgm/pkg/templexp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseParts(t *testing.T) {
+	tmpl, err := templexp.Parse(`cost $$5 ${HOST:-${file:/etc/host}}`)
+	require.NoError(t, err)
+	assert.Equal(t, `cost $$5 ${HOST:-${file:/etc/host}}`, tmpl.String())
+
+	parts := tmpl.Parts()
+	require.Len(t, parts, 2)
+	assert.Equal(t, templexp.Part{Literal: "cost $5 "}, parts[0])
+	ref := parts[1].Ref
+	require.NotNil(t, ref)
+	assert.Equal(t, "HOST", ref.Key())
+	assert.Equal(t, templexp.OpDefaultIfEmpty, ref.Op)
+	assert.Equal(t, 9, ref.Offset)
+	require.Len(t, ref.Word, 1)
+	assert.Equal(t, "file:/etc/host", ref.Word[0].Ref.Key())
+	assert.Equal(t, "file", ref.Word[0].Ref.Namespace)
+}
+
+func TestTemplateVars(t *testing.T) {
+	tmpl, err := templexp.Parse(`${A}${B-x}${C:-${D}}${E+y}${F:+z}${G?}${H:?msg}${vault:db/pw}${A}`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []templexp.Var{
+		{Name: "A", Op: templexp.OpValue, Offset: 0},
+		{Name: "B", Op: templexp.OpDefaultIfUnset, HasDefault: true, Offset: 4},
+		{Name: "C", Op: templexp.OpDefaultIfEmpty, HasDefault: true, Offset: 10},
+		{Name: "D", Op: templexp.OpValue, Offset: 15},
+		{Name: "E", Op: templexp.OpAlternateIfSet, Offset: 20},
+		{Name: "F", Op: templexp.OpAlternateIfNonEmpty, Offset: 26},
+		{Name: "G", Op: templexp.OpRequiredIfUnset, Required: true, Offset: 33},
+		{Name: "H", Op: templexp.OpRequiredIfEmpty, Required: true, Offset: 38},
+		{Namespace: "vault", Name: "db/pw", Op: templexp.OpValue, Offset: 47},
+		{Name: "A", Op: templexp.OpValue, Offset: 61},
+	}, tmpl.Vars())
+}
+
+func TestTemplateWalk(t *testing.T) {
+	tmpl, err := templexp.Parse(`${A:-${B:-${C}}}${D}`)
+	require.NoError(t, err)
+
+	var visited []string
+	tmpl.Walk(func(ref *templexp.Ref) bool {
+		visited = append(visited, ref.Name)
+		return ref.Name != "B"
+	})
+	assert.Equal(t, []string{"A", "B", "D"}, visited)
+}
+
+func TestTemplatePartsAndWalkReturnCopies(t *testing.T) {
+	tmpl, err := templexp.Parse(`${A:-${B}}`)
+	require.NoError(t, err)
+	lookup := func(name string) (string, bool) { return name, name == "B" }
+
+	parts := tmpl.Parts()
+	parts[0].Ref.Name = "B"
+	parts[0].Ref.Word[0].Ref.Name = "X"
+	tmpl.Walk(func(ref *templexp.Ref) bool {
+		ref.Op = templexp.OpValue
+		ref.Word = nil
+		return true
+	})
+
+	got, err := tmpl.Execute(lookup)
+	require.NoError(t, err)
+	assert.Equal(t, "B", got)
+	assert.Equal(t, "A", tmpl.Parts()[0].Ref.Name)
+}
+
+func TestParseStringOperators(t *testing.T) {
+	tmpl, err := templexp.Parse(`${A/${B}/${C}}${D: -2:1}${#E}`)
+	require.NoError(t, err)
+
+	parts := tmpl.Parts()
+	require.Len(t, parts, 3)
+	assert.Equal(t, templexp.OpReplaceFirst, parts[0].Ref.Op)
+	assert.Equal(t, "B", parts[0].Ref.Word[0].Ref.Name)
+	assert.Equal(t, "C", parts[0].Ref.Replacement[0].Ref.Name)
+	assert.Equal(t, templexp.OpSubstring, parts[1].Ref.Op)
+	assert.Equal(t, -2, parts[1].Ref.Start)
+	assert.Equal(t, 1, parts[1].Ref.Length)
+	assert.True(t, parts[1].Ref.HasLength)
+	assert.Equal(t, templexp.OpLength, parts[2].Ref.Op)
+
+	var names []string
+	for _, v := range tmpl.Vars() {
+		names = append(names, v.Name)
+	}
+	assert.Equal(t, []string{"A", "B", "C", "D", "E"}, names)
+}
+
+func TestParseAcceptsUnknownNamespaces(t *testing.T) {
+	tmpl, err := templexp.Parse(`${vault:secret}`)
+	require.NoError(t, err)
+	assert.Equal(t, "vault", tmpl.Vars()[0].Namespace)
+
+	_, err = templexp.Expand(`${VAR:-${vault:secret}}`, func(string) (string, bool) { return "set", true })
+	var syntaxErr *templexp.SyntaxError
+	require.ErrorAs(t, err, &syntaxErr)
+	assert.Equal(t, 14, syntaxErr.Offset)
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, text := range []string{`${`, `${1}`, `${VAR=x}`, `${A:-${B}`} {
+		_, err := templexp.Parse(text)
+		var syntaxErr *templexp.SyntaxError
+		assert.ErrorAs(t, err, &syntaxErr, text)
+	}
+}
+
+func TestOperatorString(t *testing.T) {
+	assert.Empty(t, templexp.OpValue.String())
+	assert.Equal(t, ":-", templexp.OpDefaultIfEmpty.String())
+	assert.Equal(t, "?", templexp.OpRequiredIfUnset.String())
+	assert.Equal(t, "##", templexp.OpRemoveLongestPrefix.String())
+	assert.Equal(t, "//", templexp.OpReplaceAll.String())
+	assert.Equal(t, ",,", templexp.OpLowerAll.String())
+}
+
+func TestTemplateExecute(t *testing.T) {
+	tmpl, err := templexp.Parse(`${NAME:-anonymous}@${HOST:?HOST is required}`)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for index := range 8 {
+		wg.Go(func() {
+			host := fmt.Sprintf("host-%d", index)
+			got, err := tmpl.Execute(func(name string) (string, bool) {
+				if name == "HOST" {
+					return host, true
+				}
+				return "", false
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, "anonymous@"+host, got)
+		})
+	}
+	wg.Wait()
+
+	_, err = tmpl.Execute(func(string) (string, bool) { return "", false })
+	var requiredErr *templexp.RequiredError
+	require.ErrorAs(t, err, &requiredErr)
+	assert.Equal(t, "HOST", requiredErr.Name)
+
+	_, err = tmpl.Execute(nil)
+	require.Error(t, err)
+
+	vault, err := templexp.Parse(`${vault:db}`)
+	require.NoError(t, err)
+	got, err := vault.Execute(func(string) (string, bool) { return "", false },
+		templexp.WithResolver("vault", func(argument string) (string, bool, error) { return "pw-" + argument, true, nil }))
+	require.NoError(t, err)
+	assert.Equal(t, "pw-db", got)
+}
+
+var benchmarkTemplate = strings.Repeat(`url=postgres://${DB_USER:-app}:${DB_PASSWORD:?}@${DB_HOST}:${DB_PORT:-5432}/app `, 4)
+
+func benchmarkLookup(name string) (string, bool) {
+	return "value-" + name, true
+}
+
+func BenchmarkExpand(b *testing.B) {
+	for b.Loop() {
+		if _, err := templexp.Expand(benchmarkTemplate, benchmarkLookup); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTemplateExecute(b *testing.B) {
+	tmpl, err := templexp.Parse(benchmarkTemplate)
+	require.NoError(b, err)
+	for b.Loop() {
+		if _, err := tmpl.Execute(benchmarkLookup); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package templexp
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// compilePattern compiles a shell pattern into a regular expression. *
+// matches any string, ? any character, and [...] a character class, negated
+// by a leading ! or ^ and accepting [:class:] names. A backslash makes the
+// next character literal, and an unclosed [ is literal. An anchored pattern
+// matches the whole input; otherwise the expression finds leftmost-longest
+// matches. Classes the regexp package rejects, such as [z-a], return an
+// error.
+func compilePattern(pattern string, anchored bool) (*regexp.Regexp, error) {
+	var expr strings.Builder
+	if anchored {
+		expr.WriteString(`^`)
+	}
+	expr.WriteString(`(?s:`)
+	for index := 0; index < len(pattern); {
+		ch, width := utf8.DecodeRuneInString(pattern[index:])
+		switch ch {
+		case '*':
+			expr.WriteString(`.*`)
+		case '?':
+			expr.WriteString(`.`)
+		case '\\':
+			if index+width < len(pattern) {
+				index += width
+				ch, width = utf8.DecodeRuneInString(pattern[index:])
+			}
+			expr.WriteString(regexp.QuoteMeta(string(ch)))
+		case '[':
+			if class, classWidth, ok := patternClass(pattern[index:]); ok {
+				expr.WriteString(class)
+				width = classWidth
+				break
+			}
+			expr.WriteString(`\[`)
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+		index += width
+	}
+	expr.WriteString(`)`)
+	if anchored {
+		expr.WriteString(`$`)
+	}
+
+	compiled, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, err
+	}
+	if !anchored {
+		compiled.Longest()
+	}
+	return compiled, nil
+}
+
+// patternClass translates the bracket expression at the start of text and
+// returns its length in text.
+func patternClass(text string) (string, int, bool) {
+	var class strings.Builder
+	class.WriteByte('[')
+	index := 1
+	if index < len(text) && (text[index] == '!' || text[index] == '^') {
+		class.WriteByte('^')
+		index++
+	}
+	for first := true; index < len(text); first = false {
+		switch {
+		case text[index] == ']' && !first:
+			class.WriteByte(']')
+			return class.String(), index + 1, true
+		case strings.HasPrefix(text[index:], "[:"):
+			end := strings.Index(text[index+2:], ":]")
+			if end < 0 || !isClassName(text[index+2:index+2+end]) {
+				return "", 0, false
+			}
+			class.WriteString(text[index : index+end+4])
+			index += end + 4
+		case text[index] == '\\' && index+1 < len(text):
+			class.WriteString(regexp.QuoteMeta(text[index+1 : index+2]))
+			index += 2
+		case text[index] == '-':
+			class.WriteByte('-')
+			index++
+		default:
+			ch, width := utf8.DecodeRuneInString(text[index:])
+			class.WriteString(regexp.QuoteMeta(string(ch)))
+			index += width
+		}
+	}
+
+	return "", 0, false
+}
+
+func isClassName(name string) bool {
+	switch name {
+	case "alnum", "alpha", "ascii", "blank", "cntrl", "digit", "graph",
+		"lower", "print", "punct", "space", "upper", "word", "xdigit":
+		return true
+	}
+	return false
+}
+
+// runeBoundaries returns the byte offsets at which value can be split,
+// including 0 and len(value).
+func runeBoundaries(value string) []int {
+	boundaries := make([]int, 0, len(value)+1)
+	for index := range value {
+		boundaries = append(boundaries, index)
+	}
+	return append(boundaries, len(value))
+}
+
+// trimPattern removes the shortest or longest prefix or suffix of value
+// matching pattern. A nil pattern removes nothing.
+func trimPattern(op Operator, value string, pattern *regexp.Regexp) string {
+	if pattern == nil {
+		return value
+	}
+	boundaries := runeBoundaries(value)
+	switch op {
+	case OpRemoveShortestPrefix, OpRemoveLongestPrefix:
+		for step := range boundaries {
+			index := boundaries[step]
+			if op == OpRemoveLongestPrefix {
+				index = boundaries[len(boundaries)-1-step]
+			}
+			if pattern.MatchString(value[:index]) {
+				return value[index:]
+			}
+		}
+	default:
+		for step := range boundaries {
+			index := boundaries[len(boundaries)-1-step]
+			if op == OpRemoveLongestSuffix {
+				index = boundaries[step]
+			}
+			if pattern.MatchString(value[index:]) {
+				return value[:index]
+			}
+		}
+	}
+	return value
+}
+
+// replacePattern replaces the longest match of pattern starting at the
+// leftmost position, or every such match when all is set. pattern must be
+// unanchored and leftmost-longest. Empty matches are not replaced. A nil
+// pattern replaces nothing.
+func replacePattern(value string, pattern *regexp.Regexp, replacement string, all bool) string {
+	if pattern == nil {
+		return value
+	}
+	var result strings.Builder
+	copied := 0
+	for _, match := range pattern.FindAllStringIndex(value, -1) {
+		if match[0] == match[1] {
+			continue
+		}
+		result.WriteString(value[copied:match[0]])
+		result.WriteString(replacement)
+		copied = match[1]
+		if !all {
+			break
+		}
+	}
+	result.WriteString(value[copied:])
+
+	return result.String()
+}
+
+// substring returns the characters of value from start, counted from the
+// end when negative, limited to length characters or, when length is
+// negative, ending that many characters before the end. Bounds outside value
+// yield an empty string.
+func substring(value string, start, length int, hasLength bool) string {
+	runes := []rune(value)
+	if start < 0 {
+		start += len(runes)
+	}
+	if start < 0 || start > len(runes) {
+		return ""
+	}
+	end := len(runes)
+	if hasLength {
+		if length < 0 {
+			end += length
+		} else {
+			end = min(start+length, end)
+		}
+	}
+	if end < start {
+		return ""
+	}
+
+	return string(runes[start:end])
+}
+
+func modifyCase(op Operator, value string) string {
+	switch op {
+	case OpUpperAll:
+		return strings.ToUpper(value)
+	case OpLowerAll:
+		return strings.ToLower(value)
+	}
+	first, width := utf8.DecodeRuneInString(value)
+	if width == 0 {
+		return value
+	}
+	if op == OpUpperFirst {
+		first = unicode.ToUpper(first)
+	} else {
+		first = unicode.ToLower(first)
+	}
+
+	return string(first) + value[width:]
+}
@@ -0,0 +1,56 @@
+package templexp
+
+import (
+	"errors"
+	"strings"
+)
+
+// position returns the 1-based line and byte column of offset in text.
+func position(text string, offset int) (line, column int) {
+	offset = min(max(offset, 0), len(text))
+	line = 1 + strings.Count(text[:offset], "\n")
+	column = offset - strings.LastIndexByte(text[:offset], '\n')
+	return line, column
+}
+
+// excerpt returns the line of text containing offset followed by a line with
+// a caret under offset. Tabs before offset are kept so the caret lines up.
+func excerpt(text string, offset int) string {
+	offset = min(max(offset, 0), len(text))
+	start := strings.LastIndexByte(text[:offset], '\n') + 1
+	end := strings.IndexByte(text[offset:], '\n')
+	if end < 0 {
+		end = len(text)
+	} else {
+		end += offset
+	}
+
+	var out strings.Builder
+	out.WriteString(strings.TrimSuffix(text[start:end], "\r"))
+	out.WriteByte('\n')
+	for _, ch := range text[start:offset] {
+		if ch == '\t' {
+			out.WriteByte('\t')
+		} else {
+			out.WriteByte(' ')
+		}
+	}
+	out.WriteByte('^')
+	return out.String()
+}
+
+// locateError fills in the line, column, and excerpt of errors reporting an
+// offset in text.
+func locateError(err error, text string) error {
+	var syntaxErr *SyntaxError
+	if errors.As(err, &syntaxErr) && syntaxErr.Line == 0 {
+		syntaxErr.Line, syntaxErr.Column = position(text, syntaxErr.Offset)
+		syntaxErr.Excerpt = excerpt(text, syntaxErr.Offset)
+	}
+	var requiredErr *RequiredError
+	if errors.As(err, &requiredErr) && requiredErr.Line == 0 {
+		requiredErr.Line, requiredErr.Column = position(text, requiredErr.Offset)
+		requiredErr.Excerpt = excerpt(text, requiredErr.Offset)
+	}
+	return err
+}
@@ -0,0 +1,162 @@
+package templexp
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ExpandReader copies r to w, expanding templates as Expand does. Input is
+// processed incrementally: literal text is written as it is read and each
+// ${...} reference is buffered only until its closing brace, so inputs of any
+// size use memory proportional to the longest reference and line.
+//
+// Offsets, lines, and columns in a SyntaxError or RequiredError refer to the
+// whole input. On error, the output ends before the failing reference.
+func ExpandReader(r io.Reader, w io.Writer, lookup LookupFunc, opts ...Option) error {
+	if lookup == nil {
+		return errors.New("templexp: nil lookup function")
+	}
+	s := streamExpander{in: bufio.NewReader(r), out: bufio.NewWriter(w), lookup: lookup, opts: opts}
+	err := s.run()
+	if flushErr := s.out.Flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+type streamExpander struct {
+	in     *bufio.Reader
+	out    *bufio.Writer
+	lookup LookupFunc
+	opts   []Option
+
+	offset int             // bytes consumed from the input
+	line   int             // lines before the current one
+	prefix strings.Builder // input read on the current line
+}
+
+func (s *streamExpander) run() error {
+	for {
+		chunk, err := s.in.ReadSlice('$')
+		if err != nil {
+			// No '$' in chunk: the buffer is full or the input ended.
+			if writeErr := s.write(string(chunk), string(chunk)); writeErr != nil {
+				return writeErr
+			}
+			switch {
+			case errors.Is(err, bufio.ErrBufferFull):
+				continue
+			case errors.Is(err, io.EOF):
+				return nil
+			default:
+				return err
+			}
+		}
+		// chunk is only valid until the next read, so write it before
+		// looking at the byte after the '$'.
+		if err := s.write(string(chunk[:len(chunk)-1]), string(chunk[:len(chunk)-1])); err != nil {
+			return err
+		}
+
+		next, err := s.in.Peek(1)
+		switch {
+		case err != nil && !errors.Is(err, io.EOF):
+			return err
+		case len(next) == 1 && next[0] == '$':
+			_, _ = s.in.ReadByte()
+			err = s.write("$$", "$")
+		case len(next) == 1 && next[0] == '{':
+			_, _ = s.in.ReadByte()
+			err = s.reference()
+		default:
+			err = s.write("$", "$")
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// write writes output for the input text consumed.
+func (s *streamExpander) write(consumed, output string) error {
+	s.advance(consumed)
+	_, err := s.out.WriteString(output)
+	return err
+}
+
+func (s *streamExpander) advance(text string) {
+	s.offset += len(text)
+	if index := strings.LastIndexByte(text, '\n'); index >= 0 {
+		s.line += strings.Count(text, "\n")
+		s.prefix.Reset()
+		text = text[index+1:]
+	}
+	s.prefix.WriteString(text)
+}
+
+// reference reads the rest of a reference whose "${" was consumed, tracking
+// nested references and $$ the way the parser does, and expands it.
+func (s *streamExpander) reference() error {
+	var text strings.Builder
+	text.WriteString("${")
+	for depth := 1; depth > 0; {
+		ch, err := s.in.ReadByte()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		text.WriteByte(ch)
+		switch ch {
+		case '}':
+			depth--
+		case '$':
+			next, err := s.in.Peek(1)
+			if err != nil && !errors.Is(err, io.EOF) {
+				return err
+			}
+			if len(next) == 1 && (next[0] == '{' || next[0] == '$') {
+				_, _ = s.in.ReadByte()
+				text.WriteByte(next[0])
+				if next[0] == '{' {
+					depth++
+				}
+			}
+		}
+	}
+
+	expanded, err := Expand(text.String(), s.lookup, s.opts...)
+	if err != nil {
+		return s.relocate(err, text.String())
+	}
+	return s.write(text.String(), expanded)
+}
+
+// relocate rewrites the position of an error in the reference text to a
+// position in the whole input.
+func (s *streamExpander) relocate(err error, text string) error {
+	lineText := s.prefix.String() + text
+	locate := func(offset, line, column *int, excerptText *string) {
+		at := s.prefix.Len() + *offset
+		*offset += s.offset
+		*line, *column = position(lineText, at)
+		*line += s.line
+		*excerptText = excerpt(lineText, at)
+	}
+	var syntaxErr *SyntaxError
+	if errors.As(err, &syntaxErr) {
+		locate(&syntaxErr.Offset, &syntaxErr.Line, &syntaxErr.Column, &syntaxErr.Excerpt)
+	}
+	var requiredErr *RequiredError
+	if errors.As(err, &requiredErr) {
+		locate(&requiredErr.Offset, &requiredErr.Line, &requiredErr.Column, &requiredErr.Excerpt)
+	}
+	var resolverErr *ResolverError
+	if errors.As(err, &resolverErr) {
+		resolverErr.Offset += s.offset
+	}
+	return err
+}
@@ -0,0 +1,74 @@
+package templexp_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/lwmacct/251207-go-pkg-cfgm/pkg/templexp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandReaderMatchesExpand(t *testing.T) {
+	variables := map[string]string{"HOST": "example.com", "PATH_VALUE": "/a/b/c"}
+	lookup := func(name string) (string, bool) {
+		value, found := variables[name]
+
+		return value, found
+	}
+
+	inputs := []string{
+		"",
+		"plain text\nwithout templates\n",
+		"host=${HOST}\nport=${PORT:-8080}\n",
+		"cost $$5 and $HOST and trailing $",
+		"nested ${MISSING:-${HOST:+${PATH_VALUE##*/}}} end",
+		"dollar in word ${MISSING:-a$$b} and ${MISSING:-$x}",
+		"multi-line ${MISSING:-first\nsecond}\n",
+		strings.Repeat("x", 10000) + "${HOST}" + strings.Repeat("y", 10000),
+	}
+	for _, input := range inputs {
+		want, err := templexp.Expand(input, lookup)
+		require.NoError(t, err)
+
+		var out bytes.Buffer
+		require.NoError(t, templexp.ExpandReader(strings.NewReader(input), &out, lookup))
+		assert.Equal(t, want, out.String())
+
+		out.Reset()
+		require.NoError(t, templexp.ExpandReader(iotest.OneByteReader(strings.NewReader(input)), &out, lookup))
+		assert.Equal(t, want, out.String())
+	}
+}
+
+func TestExpandReaderErrors(t *testing.T) {
+	lookup := func(string) (string, bool) { return "", false }
+
+	var out bytes.Buffer
+	err := templexp.ExpandReader(strings.NewReader("a: 1\nb: x ${PORT:?port is required}\nc: 3\n"), &out, lookup)
+	var requiredErr *templexp.RequiredError
+	require.ErrorAs(t, err, &requiredErr)
+	assert.Equal(t, 10, requiredErr.Offset)
+	assert.Equal(t, 2, requiredErr.Line)
+	assert.Equal(t, 6, requiredErr.Column)
+	assert.Equal(t, "b: x ${PORT:?port is required}\n     ^", requiredErr.Excerpt)
+	assert.Equal(t, "a: 1\nb: x ", out.String())
+
+	err = templexp.ExpandReader(strings.NewReader("ok\n${HOST"), &out, lookup)
+	var syntaxErr *templexp.SyntaxError
+	require.ErrorAs(t, err, &syntaxErr)
+	assert.Equal(t, 3, syntaxErr.Offset)
+	assert.Equal(t, 2, syntaxErr.Line)
+	assert.Equal(t, 1, syntaxErr.Column)
+
+	err = templexp.ExpandReader(strings.NewReader("${HOST}"), &out, lookup, templexp.WithStrict())
+	require.ErrorAs(t, err, &requiredErr)
+	assert.Equal(t, "HOST", requiredErr.Name)
+
+	err = templexp.ExpandReader(iotest.ErrReader(assert.AnError), &out, lookup)
+	require.ErrorIs(t, err, assert.AnError)
+
+	require.EqualError(t, templexp.ExpandReader(strings.NewReader(""), &out, nil), "templexp: nil lookup function")
+}
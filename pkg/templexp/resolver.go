@@ -0,0 +1,154 @@
+package templexp
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// Resolver resolves the argument of a namespaced reference such as
+// ${file:/run/secrets/db}. The boolean reports whether a value exists; an
+// absent value behaves like an unset variable for the :-, :+ and :?
+// operators.
+type Resolver func(argument string) (value string, found bool, err error)
+
+// ResolverError reports a failure returned by a Resolver.
+type ResolverError struct {
+	Namespace string
+	Argument  string
+	Offset    int
+	Err       error
+}
+
+func (e *ResolverError) Error() string {
+	return fmt.Sprintf("templexp: ${%s:%s}: %v", e.Namespace, e.Argument, e.Err)
+}
+
+func (e *ResolverError) Unwrap() error { return e.Err }
+
+// Option configures Expand.
+type Option func(*options)
+
+type options struct {
+	resolvers        map[string]Resolver
+	strict           bool
+	allowed          map[string]bool
+	strictNamespaces map[string]bool
+}
+
+// WithResolver makes ${namespace:argument} resolve through resolver.
+// Namespaces use the variable name syntax. A later resolver for the same
+// namespace replaces the earlier one.
+func WithResolver(namespace string, resolver Resolver) Option {
+	if !isName(namespace) {
+		panic(fmt.Sprintf("templexp: invalid resolver namespace %q", namespace))
+	}
+	if resolver == nil {
+		panic("templexp: nil resolver")
+	}
+	return func(o *options) {
+		if o.resolvers == nil {
+			o.resolvers = make(map[string]Resolver)
+		}
+		o.resolvers[namespace] = resolver
+	}
+}
+
+// WithStrict makes a reference to an unset variable a [RequiredError] unless
+// its operator handles unset variables, as -, +, ? and their colon forms do.
+// Names in allowed still expand to an empty string when unset; namespaced
+// references are listed as ns:argument.
+func WithStrict(allowed ...string) Option {
+	return func(o *options) {
+		o.strict = true
+		if o.allowed == nil {
+			o.allowed = make(map[string]bool)
+		}
+		for _, name := range allowed {
+			o.allowed[name] = true
+		}
+	}
+}
+
+// WithStrictNamespace applies WithStrict to the references of namespace
+// only, so an unset ${namespace:argument} is a [RequiredError] unless its
+// operator handles unset values. Names in the allowed list of WithStrict
+// still expand to an empty string.
+func WithStrictNamespace(namespace string) Option {
+	if !isName(namespace) {
+		panic(fmt.Sprintf("templexp: invalid resolver namespace %q", namespace))
+	}
+	return func(o *options) {
+		if o.strictNamespaces == nil {
+			o.strictNamespaces = make(map[string]bool)
+		}
+		o.strictNamespaces[namespace] = true
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
+}
+
+// FileResolver reads the named file, dropping one trailing line break. A
+// missing file is reported as not found.
+func FileResolver() Resolver {
+	return func(path string) (string, bool, error) {
+		content, err := os.ReadFile(path) //nolint:gosec // the template names the file
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+		value := strings.TrimSuffix(string(content), "\n")
+		return strings.TrimSuffix(value, "\r"), true, nil
+	}
+}
+
+// EnvResolver resolves names through lookup, so ${env:HOME} matches ${HOME}
+// when lookup is the variable lookup.
+func EnvResolver(lookup LookupFunc) Resolver {
+	if lookup == nil {
+		panic("templexp: nil lookup function")
+	}
+	return func(name string) (string, bool, error) {
+		value, found := lookup(name)
+		return value, found, nil
+	}
+}
+
+// Base64Resolver decodes standard base64 with or without padding.
+func Base64Resolver() Resolver {
+	return func(encoded string) (string, bool, error) {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			decoded, err = base64.RawStdEncoding.DecodeString(encoded)
+		}
+		if err != nil {
+			return "", false, errors.New("invalid base64")
+		}
+		return string(decoded), true, nil
+	}
+}
+
+func isName(text string) bool {
+	if text == "" || !isNameStart(text[0]) {
+		return false
+	}
+	for index := 1; index < len(text); index++ {
+		if !isNameChar(text[index]) {
+			return false
+		}
+	}
+	return true
+}
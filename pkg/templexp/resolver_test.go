@@ -0,0 +1,111 @@
+package templexp_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lwmacct/251207-go-pkg-cfgm/pkg/templexp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandResolvers(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "db")
+	require.NoError(t, os.WriteFile(secret, []byte("s3cret\n"), 0o600))
+	variables := map[string]string{"HOME": "/home/app", "EMPTY": "", "file": "abcdef"}
+	lookup := func(name string) (string, bool) {
+		value, found := variables[name]
+		return value, found
+	}
+	opts := []templexp.Option{
+		templexp.WithResolver("file", templexp.FileResolver()),
+		templexp.WithResolver("env", templexp.EnvResolver(lookup)),
+		templexp.WithResolver("base64", templexp.Base64Resolver()),
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{name: "file", template: "pw=${file:" + secret + "}", want: "pw=s3cret"},
+		{name: "missing file default", template: "${file:" + filepath.Join(dir, "missing") + ":-none}", want: "none"},
+		{name: "env", template: "${env:HOME}/data", want: "/home/app/data"},
+		{name: "env empty alternate", template: "${env:EMPTY:+set}", want: ""},
+		{name: "base64", template: "${base64:aGVsbG8=}", want: "hello"},
+		{name: "base64 without padding", template: "${base64:aGVsbG8}", want: "hello"},
+		{name: "argument with colon", template: "${env:A:B-c}", want: ""},
+		{name: "operator default", template: "${HOME:-x}", want: "/home/app"},
+		{name: "numeric argument is a substring", template: "${file:1:2}", want: "bc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := templexp.Expand(tt.template, lookup, opts...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := templexp.Expand("${file:"+filepath.Join(dir, "missing")+":?secret file is required}", lookup, opts...)
+	var requiredErr *templexp.RequiredError
+	require.ErrorAs(t, err, &requiredErr)
+	assert.Equal(t, "file:"+filepath.Join(dir, "missing"), requiredErr.Name)
+
+	_, err = templexp.Expand("x${base64:!!}", lookup, opts...)
+	var resolverErr *templexp.ResolverError
+	require.ErrorAs(t, err, &resolverErr)
+	assert.Equal(t, "base64", resolverErr.Namespace)
+	assert.Equal(t, 1, resolverErr.Offset)
+	assert.EqualError(t, err, "templexp: ${base64:!!}: invalid base64")
+}
+
+func TestExpandResolverSyntaxErrors(t *testing.T) {
+	lookup := func(string) (string, bool) { return "", false }
+	opts := []templexp.Option{templexp.WithResolver("file", templexp.FileResolver())}
+	tests := []struct {
+		name     string
+		template string
+		offset   int
+		message  string
+	}{
+		{name: "unknown namespace", template: `${vault:db}`, offset: 7, message: `unknown resolver namespace "vault"`},
+		{name: "empty argument", template: `${file:}`, offset: 7, message: "expected resolver argument"},
+		{name: "nested argument", template: `${file:${DIR}/x}`, offset: 7, message: "interpolation is not supported"},
+		{name: "unclosed", template: `${file:/x`, offset: 0, message: "unclosed interpolation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := templexp.Expand(tt.template, lookup, opts...)
+			var syntaxErr *templexp.SyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			assert.Equal(t, tt.offset, syntaxErr.Offset)
+			assert.Contains(t, syntaxErr.Message, tt.message)
+		})
+	}
+}
+
+func TestResolverCallsAreCached(t *testing.T) {
+	calls := 0
+	resolver := func(argument string) (string, bool, error) {
+		calls++
+		if argument == "fail" {
+			return "", false, errors.New("unavailable")
+		}
+		return "v-" + argument, true, nil
+	}
+	lookup := func(string) (string, bool) { return "", false }
+
+	got, err := templexp.Expand("${kv:a}${kv:a}${kv:b}", lookup, templexp.WithResolver("kv", resolver))
+	require.NoError(t, err)
+	assert.Equal(t, "v-av-av-b", got)
+	assert.Equal(t, 2, calls)
+
+	_, err = templexp.Expand("${kv:fail}", lookup, templexp.WithResolver("kv", resolver))
+	require.ErrorContains(t, err, "unavailable")
+
+	assert.Panics(t, func() { templexp.WithResolver("1bad", resolver) })
+	assert.Panics(t, func() { templexp.WithResolver("kv", nil) })
+}
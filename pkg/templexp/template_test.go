@@ -100,13 +100,23 @@ func TestExpandRejectsInvalidSyntax(t *testing.T) {
 		name     string
 		template string
 		offset   int
+		message  string
 	}{
-		{name: "empty name", template: `${}`, offset: 2},
-		{name: "invalid name", template: `${1VAR}`, offset: 2},
-		{name: "unclosed", template: `a${VAR`, offset: 1},
-		{name: "assignment", template: `${VAR:=value}`, offset: 5},
-		{name: "assignment without colon", template: `${VAR=value}`, offset: 5},
-		{name: "unsupported operator", template: `${VAR:value}`, offset: 5},
+		{name: "empty name", template: `${}`, offset: 2, message: "expected variable name"},
+		{name: "invalid name", template: `${1VAR}`, offset: 2, message: "expected variable name"},
+		{name: "unclosed", template: `a${VAR`, offset: 1, message: "unclosed interpolation"},
+		{name: "assignment", template: `${VAR:=value}`, offset: 5, message: "assignment operators := and = are not supported"},
+		{name: "assignment without colon", template: `${VAR=value}`, offset: 5, message: "assignment operators := and = are not supported"},
+		{name: "unsupported operator", template: `${VAR:value}`, offset: 5, message: `unknown resolver namespace "VAR"`},
+		{name: "substring without offset", template: `${VAR:}`, offset: 6, message: "expected resolver argument"},
+		{name: "invalid substring", template: `${VAR:1:x}`, offset: 5, message: "invalid substring expression"},
+		{name: "invalid substring offset", template: `${VAR: x}`, offset: 5, message: "invalid substring expression"},
+		{name: "length without name", template: `${#}`, offset: 3, message: "expected variable name"},
+		{name: "length with operator", template: `${#VAR:-x}`, offset: 6, message: "expected } after length reference"},
+		{name: "case with pattern", template: `${VAR^^a}`, offset: 7, message: "case modification does not take a pattern"},
+		{name: "unclosed pattern", template: `${VAR#a`, offset: 0, message: "unclosed interpolation"},
+		{name: "unclosed replacement", template: `x${VAR/a/b`, offset: 1, message: "unclosed interpolation"},
+		{name: "invalid pattern class", template: `${VAR#[z-a]}`, offset: 6, message: `invalid pattern "[z-a]"`},
 	}
 
 	for _, tt := range tests {
@@ -117,6 +127,7 @@ func TestExpandRejectsInvalidSyntax(t *testing.T) {
 			var syntaxErr *templexp.SyntaxError
 			require.ErrorAs(t, err, &syntaxErr)
 			assert.Equal(t, tt.offset, syntaxErr.Offset)
+			assert.Equal(t, tt.message, syntaxErr.Message)
 		})
 	}
 }
@@ -146,3 +157,169 @@ func TestExpandCachesLookupResults(t *testing.T) {
 	assert.Equal(t, "value-value", got)
 	assert.Equal(t, 1, calls)
 }
+
+func TestExpandStringOperators(t *testing.T) {
+	variables := map[string]string{
+		"PATH_VALUE": "/usr/local/lib/app.tar.gz",
+		"URL":        "https://example.com/a/b",
+		"NAME":       "hello world",
+		"UPPER":      "HELLO",
+		"UNICODE":    "héllo",
+		"EMPTY":      "",
+		"SUFFIX":     ".gz",
+	}
+	lookup := func(name string) (string, bool) {
+		value, found := variables[name]
+
+		return value, found
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{name: "shortest prefix", template: `${PATH_VALUE#*/}`, want: "usr/local/lib/app.tar.gz"},
+		{name: "longest prefix", template: `${PATH_VALUE##*/}`, want: "app.tar.gz"},
+		{name: "shortest suffix", template: `${PATH_VALUE%.*}`, want: "/usr/local/lib/app.tar"},
+		{name: "longest suffix", template: `${PATH_VALUE%%.*}`, want: "/usr/local/lib/app"},
+		{name: "unmatched prefix", template: `${PATH_VALUE#x*}`, want: "/usr/local/lib/app.tar.gz"},
+		{name: "nested pattern", template: `${PATH_VALUE%${SUFFIX}}`, want: "/usr/local/lib/app.tar"},
+		{name: "scheme", template: `${URL#*://}`, want: "example.com/a/b"},
+		{name: "character class", template: `${NAME##[a-h]*[!a-z]}`, want: "world"},
+		{name: "named class", template: `${NAME%%[[:space:]]*}`, want: "hello"},
+		{name: "question mark", template: `${NAME#??}`, want: "llo world"},
+		{name: "escaped star", template: `${NAME#\*}`, want: "hello world"},
+		{name: "replace first", template: `${NAME/o/0}`, want: "hell0 world"},
+		{name: "replace all", template: `${NAME//o/0}`, want: "hell0 w0rld"},
+		{name: "replace longest", template: `${NAME/l*o/L}`, want: "heLrld"},
+		{name: "delete", template: `${NAME// }`, want: "helloworld"},
+		{name: "replace slash", template: `${URL//\//_}`, want: "https:__example.com_a_b"},
+		{name: "slash in replacement", template: `${URL/\/\/example/\/\/mirror\\}`, want: "https://mirror\\.com/a/b"},
+		{name: "escaped slash both sides", template: `${PATH_VALUE/\//\/}`, want: "/usr/local/lib/app.tar.gz"},
+		{name: "backslash kept in word", template: `${MISSING:-a\/b}`, want: `a\/b`},
+		{name: "replace nested", template: `${NAME/world/${UPPER}}`, want: "hello HELLO"},
+		{name: "empty pattern", template: `${NAME//}`, want: "hello world"},
+		{name: "skip empty matches", template: `${NAME//o*/_}`, want: "hell_"},
+		{name: "replace first non-empty", template: `${NAME/[lo]*/_}`, want: "he_"},
+		{name: "substring", template: `${NAME:6}`, want: "world"},
+		{name: "substring length", template: `${NAME:0:5}`, want: "hello"},
+		{name: "negative offset", template: `${NAME: -5}`, want: "world"},
+		{name: "negative length", template: `${NAME:2:-2}`, want: "llo wor"},
+		{name: "offset past end", template: `${NAME:20}`, want: ""},
+		{name: "unicode substring", template: `${UNICODE:1:2}`, want: "él"},
+		{name: "length", template: `${#NAME}`, want: "11"},
+		{name: "unicode length", template: `${#UNICODE}`, want: "5"},
+		{name: "unset length", template: `${#MISSING}`, want: "0"},
+		{name: "upper first", template: `${NAME^}`, want: "Hello world"},
+		{name: "upper all", template: `${UNICODE^^}`, want: "HÉLLO"},
+		{name: "lower first", template: `${UPPER,}`, want: "hELLO"},
+		{name: "lower all", template: `${UPPER,,}`, want: "hello"},
+		{name: "empty case", template: `${EMPTY^^}`, want: ""},
+		{name: "unset pattern", template: `${MISSING%%.*}`, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := templexp.Expand(tt.template, lookup)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExpandStrict(t *testing.T) {
+	variables := map[string]string{"SET": "value", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		value, found := variables[name]
+
+		return value, found
+	}
+	opts := []templexp.Option{
+		templexp.WithStrict("OPTIONAL", "env:OPTIONAL"),
+		templexp.WithResolver("env", templexp.EnvResolver(lookup)),
+	}
+
+	for _, template := range []string{
+		`${SET}${EMPTY}`, `${MISSING:-x}`, `${MISSING-x}`, `${MISSING:+x}`, `${MISSING+x}`,
+		`${OPTIONAL}`, `${env:OPTIONAL}`, `${SET:-${MISSING}}`, `$${MISSING}`,
+	} {
+		_, err := templexp.Expand(template, lookup, opts...)
+		assert.NoError(t, err, template)
+	}
+
+	tests := []struct {
+		template string
+		name     string
+		offset   int
+	}{
+		{template: `a=${MISSING}`, name: "MISSING", offset: 2},
+		{template: `${MISSING:-${OTHER}}`, name: "OTHER", offset: 11},
+		{template: `${MISSING#x}`, name: "MISSING", offset: 0},
+		{template: `${#MISSING}`, name: "MISSING", offset: 0},
+		{template: `${env:MISSING}`, name: "env:MISSING", offset: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			_, err := templexp.Expand(tt.template, lookup, opts...)
+			var requiredErr *templexp.RequiredError
+			require.ErrorAs(t, err, &requiredErr)
+			assert.Equal(t, tt.name, requiredErr.Name)
+			assert.Equal(t, tt.offset, requiredErr.Offset)
+			assert.EqualError(t, err, "templexp: "+tt.name+": variable is unset")
+		})
+	}
+
+	got, err := templexp.Expand(`${MISSING}`, lookup)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestExpandStrictNamespace(t *testing.T) {
+	lookup := func(string) (string, bool) { return "", false }
+	opts := []templexp.Option{
+		templexp.WithResolver("env", templexp.EnvResolver(lookup)),
+		templexp.WithStrictNamespace("env"),
+	}
+
+	got, err := templexp.Expand(`${MISSING}${env:MISSING:-x}${env:MISSING:+y}`, lookup, opts...)
+	require.NoError(t, err)
+	assert.Equal(t, "x", got)
+
+	_, err = templexp.Expand(`a=${env:MISSING}`, lookup, opts...)
+	var requiredErr *templexp.RequiredError
+	require.ErrorAs(t, err, &requiredErr)
+	assert.Equal(t, "env:MISSING", requiredErr.Name)
+	assert.Equal(t, 2, requiredErr.Offset)
+
+	_, err = templexp.Expand(`${env:MISSING}`, lookup, append(opts, templexp.WithStrict("env:MISSING"))...)
+	require.NoError(t, err)
+
+	assert.Panics(t, func() { templexp.WithStrictNamespace("bad name") })
+}
+
+func TestExpandErrorPositions(t *testing.T) {
+	lookup := func(string) (string, bool) { return "", false }
+
+	_, err := templexp.Expand("first line\n\turl: ${HOST=x}\n", lookup)
+	var syntaxErr *templexp.SyntaxError
+	require.ErrorAs(t, err, &syntaxErr)
+	assert.Equal(t, 23, syntaxErr.Offset)
+	assert.Equal(t, 2, syntaxErr.Line)
+	assert.Equal(t, 13, syntaxErr.Column)
+	assert.Equal(t, "\turl: ${HOST=x}\n\t           ^", syntaxErr.Excerpt)
+	assert.EqualError(t, err, "templexp: syntax error at line 2, column 13: assignment operators := and = are not supported")
+
+	_, err = templexp.Expand("a\nb=${PORT:?port is required}", lookup)
+	var requiredErr *templexp.RequiredError
+	require.ErrorAs(t, err, &requiredErr)
+	assert.Equal(t, 2, requiredErr.Line)
+	assert.Equal(t, 3, requiredErr.Column)
+	assert.Equal(t, "b=${PORT:?port is required}\n  ^", requiredErr.Excerpt)
+	assert.EqualError(t, err, "templexp: PORT: port is required")
+
+	_, err = templexp.Expand("${vault:x}", lookup)
+	require.ErrorAs(t, err, &syntaxErr)
+	assert.Equal(t, 1, syntaxErr.Line)
+	assert.Equal(t, 8, syntaxErr.Column)
+	assert.Equal(t, "${vault:x}\n       ^", syntaxErr.Excerpt)
+}
@@ -3,92 +3,162 @@ package templexp
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // LookupFunc resolves a variable by name. The boolean reports whether the
 // variable is set, independently of whether its value is empty.
 type LookupFunc func(name string) (value string, found bool)
 
-// SyntaxError reports an invalid interpolation expression.
+// SyntaxError reports an invalid interpolation expression. Line and Column
+// are 1-based, with Column counted in bytes, and Excerpt shows the line of the
+// template with a caret under the offending byte.
 type SyntaxError struct {
 	Offset  int
+	Line    int
+	Column  int
+	Excerpt string
 	Message string
 }
 
 func (e *SyntaxError) Error() string {
-	return fmt.Sprintf("templexp: syntax error at byte %d: %s", e.Offset, e.Message)
+	if e.Line == 0 {
+		return fmt.Sprintf("templexp: syntax error at byte %d: %s", e.Offset, e.Message)
+	}
+	return fmt.Sprintf("templexp: syntax error at line %d, column %d: %s", e.Line, e.Column, e.Message)
 }
 
-// RequiredError reports a variable rejected by ? or :?.
+// RequiredError reports a variable rejected by ? or :?, or an unset variable
+// rejected by WithStrict. Line, Column, and Excerpt locate the reference as
+// in SyntaxError.
 type RequiredError struct {
 	Name    string
 	Message string
 	Offset  int
+	Line    int
+	Column  int
+	Excerpt string
 }
 
 func (e *RequiredError) Error() string {
 	return fmt.Sprintf("templexp: %s: %s", e.Name, e.Message)
 }
 
-type operator uint8
+// Operator is the operator of a reference, such as :- in ${VAR:-word}.
+type Operator uint8
 
 const (
-	opValue operator = iota
-	opDefaultIfUnset
-	opDefaultIfEmpty
-	opAlternateIfSet
-	opAlternateIfNonEmpty
-	opRequiredIfUnset
-	opRequiredIfEmpty
+	OpValue                Operator = iota // ${VAR}
+	OpDefaultIfUnset                       // ${VAR-word}
+	OpDefaultIfEmpty                       // ${VAR:-word}
+	OpAlternateIfSet                       // ${VAR+word}
+	OpAlternateIfNonEmpty                  // ${VAR:+word}
+	OpRequiredIfUnset                      // ${VAR?word}
+	OpRequiredIfEmpty                      // ${VAR:?word}
+	OpRemoveShortestPrefix                 // ${VAR#pattern}
+	OpRemoveLongestPrefix                  // ${VAR##pattern}
+	OpRemoveShortestSuffix                 // ${VAR%pattern}
+	OpRemoveLongestSuffix                  // ${VAR%%pattern}
+	OpReplaceFirst                         // ${VAR/pattern/replacement}
+	OpReplaceAll                           // ${VAR//pattern/replacement}
+	OpSubstring                            // ${VAR:offset} or ${VAR:offset:length}
+	OpLength                               // ${#VAR}
+	OpUpperFirst                           // ${VAR^}
+	OpUpperAll                             // ${VAR^^}
+	OpLowerFirst                           // ${VAR,}
+	OpLowerAll                             // ${VAR,,}
 )
 
-type template struct {
-	parts []part
+var operatorStrings = [...]string{
+	OpDefaultIfUnset:       "-",
+	OpDefaultIfEmpty:       ":-",
+	OpAlternateIfSet:       "+",
+	OpAlternateIfNonEmpty:  ":+",
+	OpRequiredIfUnset:      "?",
+	OpRequiredIfEmpty:      ":?",
+	OpRemoveShortestPrefix: "#",
+	OpRemoveLongestPrefix:  "##",
+	OpRemoveShortestSuffix: "%",
+	OpRemoveLongestSuffix:  "%%",
+	OpReplaceFirst:         "/",
+	OpReplaceAll:           "//",
+	OpSubstring:            ":",
+	OpLength:               "#",
+	OpUpperFirst:           "^",
+	OpUpperAll:             "^^",
+	OpLowerFirst:           ",",
+	OpLowerAll:             ",,",
 }
 
-type part struct {
-	literal   string
-	expansion *expansion
+// String returns the operator as written in a template, or "" for OpValue.
+// OpLength, which is written before the name, also returns "#".
+func (o Operator) String() string {
+	if int(o) < len(operatorStrings) {
+		return operatorStrings[o]
+	}
+	return ""
 }
 
-type expansion struct {
-	name   string
-	op     operator
-	word   template
-	offset int
+// operators lists the operators written after a variable name, longest
+// first so that ## is preferred over #.
+var operators = []Operator{
+	OpDefaultIfEmpty, OpAlternateIfNonEmpty, OpRequiredIfEmpty,
+	OpRemoveLongestPrefix, OpRemoveLongestSuffix, OpReplaceAll, OpUpperAll, OpLowerAll,
+	OpDefaultIfUnset, OpAlternateIfSet, OpRequiredIfUnset,
+	OpRemoveShortestPrefix, OpRemoveShortestSuffix, OpReplaceFirst, OpUpperFirst, OpLowerFirst,
 }
 
 type parser struct {
 	text   string
 	offset int
 	depth  int
+	// replacement is set while parsing the replacement of / and //, where
+	// \/ and \\ stand for / and \.
+	replacement bool
 }
 
 const maxNestingDepth = 100
 
-func (p *parser) parse(stopAtBrace bool, openingOffset int) (template, error) {
-	result := template{}
+// parse reads parts until one of the terminators, which is consumed and
+// returned, or until the end of the text when terminators is empty.
+func (p *parser) parse(terminators string, openingOffset int) ([]Part, byte, error) {
+	var parts []Part
 	var literal strings.Builder
 
 	flushLiteral := func() {
 		if literal.Len() == 0 {
 			return
 		}
-		result.parts = append(result.parts, part{literal: literal.String()})
+		parts = append(parts, Part{Literal: literal.String()})
 		literal.Reset()
 	}
 
 	for p.offset < len(p.text) {
-		if stopAtBrace && p.text[p.offset] == '}' {
+		ch := p.text[p.offset]
+		if strings.IndexByte(terminators, ch) >= 0 {
 			p.offset++
 			flushLiteral()
 
-			return result, nil
+			return parts, ch, nil
+		}
+		if ch == '\\' && terminators == "/}" && p.offset+1 < len(p.text) && p.text[p.offset+1] == '/' {
+			// \/ keeps a slash in a replacement pattern; the escape is
+			// left for the pattern matcher.
+			literal.WriteString(`\/`)
+			p.offset += 2
+			continue
+		}
+		if ch == '\\' && p.replacement && p.offset+1 < len(p.text) && strings.IndexByte(`/\`, p.text[p.offset+1]) >= 0 {
+			literal.WriteByte(p.text[p.offset+1])
+			p.offset += 2
+			continue
 		}
 
-		if p.text[p.offset] != '$' || p.offset+1 >= len(p.text) {
-			literal.WriteByte(p.text[p.offset])
+		if ch != '$' || p.offset+1 >= len(p.text) {
+			literal.WriteByte(ch)
 			p.offset++
 			continue
 		}
@@ -99,94 +169,217 @@ func (p *parser) parse(stopAtBrace bool, openingOffset int) (template, error) {
 			p.offset += 2
 		case '{':
 			flushLiteral()
-			expr, err := p.parseExpansion()
+			ref, err := p.parseReference()
 			if err != nil {
-				return template{}, err
+				return nil, 0, err
 			}
-			result.parts = append(result.parts, part{expansion: &expr})
+			parts = append(parts, Part{Ref: ref})
 		default:
 			literal.WriteByte('$')
 			p.offset++
 		}
 	}
 
-	if stopAtBrace {
-		return template{}, syntaxError(openingOffset, "unclosed interpolation")
+	if terminators != "" {
+		return nil, 0, syntaxError(openingOffset, "unclosed interpolation")
 	}
 	flushLiteral()
 
-	return result, nil
+	return parts, 0, nil
 }
 
-func (p *parser) parseExpansion() (expansion, error) {
+func (p *parser) parseReference() (*Ref, error) {
 	openingOffset := p.offset
 	if p.depth >= maxNestingDepth {
-		return expansion{}, syntaxError(openingOffset, "maximum interpolation nesting depth exceeded")
+		return nil, syntaxError(openingOffset, "maximum interpolation nesting depth exceeded")
 	}
 	p.depth++
-	defer func() { p.depth-- }()
+	replacement := p.replacement
+	p.replacement = false
+	defer func() { p.depth--; p.replacement = replacement }()
 
 	p.offset += 2
+	ref := &Ref{Offset: openingOffset}
+	if p.offset < len(p.text) && p.text[p.offset] == '#' {
+		ref.Op = OpLength
+		p.offset++
+	}
 	nameStart := p.offset
 	if p.offset >= len(p.text) || !isNameStart(p.text[p.offset]) {
-		return expansion{}, syntaxError(p.offset, "expected variable name")
+		return nil, syntaxError(p.offset, "expected variable name")
 	}
 	p.offset++
 	for p.offset < len(p.text) && isNameChar(p.text[p.offset]) {
 		p.offset++
 	}
+	ref.Name = p.text[nameStart:p.offset]
+
+	if ref.Op == OpLength {
+		if p.offset >= len(p.text) || p.text[p.offset] != '}' {
+			return nil, syntaxError(p.offset, "expected } after length reference")
+		}
+		p.offset++
+
+		return ref, nil
+	}
 
-	expr := expansion{name: p.text[nameStart:p.offset], offset: openingOffset}
+	if strings.HasPrefix(p.text[p.offset:], ":=") || strings.HasPrefix(p.text[p.offset:], "=") {
+		return nil, syntaxError(p.offset, "assignment operators := and = are not supported")
+	}
+	if strings.HasPrefix(p.text[p.offset:], ":") && !isColonOperator(p.text[p.offset:]) {
+		if p.parseSubstring(ref) {
+			return ref, nil
+		}
+		if err := p.parseResolverArgument(ref); err != nil {
+			return nil, err
+		}
+	}
 	if p.offset >= len(p.text) {
-		return expansion{}, syntaxError(openingOffset, "unclosed interpolation")
+		return nil, syntaxError(openingOffset, "unclosed interpolation")
 	}
 	if p.text[p.offset] == '}' {
 		p.offset++
 
-		return expr, nil
+		return ref, nil
 	}
 
-	op, width, ok := parseOperator(p.text[p.offset:])
+	op, ok := parseOperator(p.text[p.offset:])
 	if !ok {
-		return expansion{}, syntaxError(p.offset, "unsupported or invalid operator")
+		return nil, syntaxError(p.offset, "unsupported or invalid operator")
 	}
-	expr.op = op
-	p.offset += width
+	ref.Op = op
+	p.offset += len(op.String())
+	ref.wordOffset = p.offset
+
+	switch op {
+	case OpUpperFirst, OpUpperAll, OpLowerFirst, OpLowerAll:
+		if p.offset >= len(p.text) || p.text[p.offset] != '}' {
+			return nil, syntaxError(p.offset, "case modification does not take a pattern")
+		}
+		p.offset++
 
-	word, err := p.parse(true, openingOffset)
+		return ref, nil
+	case OpReplaceFirst, OpReplaceAll:
+		pattern, terminator, err := p.parse("/}", openingOffset)
+		if err != nil {
+			return nil, err
+		}
+		ref.Word = pattern
+		if terminator == '/' {
+			p.replacement = true
+			ref.Replacement, _, err = p.parse("}", openingOffset)
+			p.replacement = false
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return ref, nil
+	}
+
+	word, _, err := p.parse("}", openingOffset)
 	if err != nil {
-		return expansion{}, err
+		return nil, err
 	}
-	expr.word = word
+	ref.Word = word
 
-	return expr, nil
+	return ref, nil
 }
 
-func parseOperator(text string) (operator, int, bool) {
-	if len(text) >= 2 && text[0] == ':' {
-		switch text[1] {
-		case '-':
-			return opDefaultIfEmpty, 2, true
-		case '+':
-			return opAlternateIfNonEmpty, 2, true
-		case '?':
-			return opRequiredIfEmpty, 2, true
+// parseSubstring reads :offset} or :offset:length} at the current offset.
+// Offset and length are decimal integers; a negative offset needs a space
+// after the colon to tell it from :-. When the text does not have this form
+// the reference is a resolver reference and nothing is consumed.
+func (p *parser) parseSubstring(ref *Ref) bool {
+	text := p.text[p.offset+1:]
+	start, width, ok := parseSubstringNumber(text)
+	if !ok {
+		return false
+	}
+	text = text[width:]
+	consumed := 1 + width
+	length, hasLength := 0, false
+	if strings.HasPrefix(text, ":") {
+		var lengthWidth int
+		if length, lengthWidth, ok = parseSubstringNumber(text[1:]); !ok {
+			return false
 		}
+		hasLength = true
+		text = text[1+lengthWidth:]
+		consumed += 1 + lengthWidth
 	}
-	if text == "" {
-		return opValue, 0, false
+	if !strings.HasPrefix(text, "}") {
+		return false
 	}
 
-	switch text[0] {
-	case '-':
-		return opDefaultIfUnset, 1, true
-	case '+':
-		return opAlternateIfSet, 1, true
-	case '?':
-		return opRequiredIfUnset, 1, true
-	default:
-		return opValue, 0, false
+	ref.Op, ref.Start, ref.Length, ref.HasLength = OpSubstring, start, length, hasLength
+	p.offset += consumed + 1
+
+	return true
+}
+
+// parseSubstringNumber parses an integer surrounded by optional spaces.
+func parseSubstringNumber(text string) (int, int, bool) {
+	index := 0
+	for index < len(text) && text[index] == ' ' {
+		index++
+	}
+	numberStart := index
+	if index < len(text) && text[index] == '-' {
+		index++
 	}
+	digitsStart := index
+	for index < len(text) && text[index] >= '0' && text[index] <= '9' {
+		index++
+	}
+	if index == digitsStart {
+		return 0, 0, false
+	}
+	number, err := strconv.Atoi(text[numberStart:index])
+	if err != nil {
+		return 0, 0, false
+	}
+	for index < len(text) && text[index] == ' ' {
+		index++
+	}
+
+	return number, index, true
+}
+
+// parseResolverArgument reads the argument of ${namespace:argument}, which
+// ends at the closing brace or at a :-, :+ or :? operator.
+func (p *parser) parseResolverArgument(ref *Ref) error {
+	ref.namespaceOffset = p.offset
+	p.offset++
+	argumentStart := p.offset
+	for p.offset < len(p.text) && p.text[p.offset] != '}' {
+		if p.text[p.offset] == '$' {
+			return syntaxError(p.offset, "interpolation is not supported in resolver arguments")
+		}
+		if isColonOperator(p.text[p.offset:]) {
+			break
+		}
+		p.offset++
+	}
+	if p.offset == argumentStart {
+		return syntaxError(argumentStart, "expected resolver argument")
+	}
+	ref.Namespace, ref.Name = ref.Name, p.text[argumentStart:p.offset]
+	return nil
+}
+
+func isColonOperator(text string) bool {
+	return len(text) >= 2 && text[0] == ':' && strings.IndexByte("-+?", text[1]) >= 0
+}
+
+func parseOperator(text string) (Operator, bool) {
+	for _, op := range operators {
+		if strings.HasPrefix(text, op.String()) {
+			return op, true
+		}
+	}
+
+	return OpValue, false
 }
 
 func isNameStart(ch byte) bool {
@@ -201,25 +394,49 @@ func syntaxError(offset int, message string) error {
 	return &SyntaxError{Offset: offset, Message: message}
 }
 
+// checkNamespaces rejects references to namespaces without a resolver,
+// including references in words that may never be evaluated.
+func checkNamespaces(t *Template, resolvers map[string]Resolver) error {
+	var err error
+	walkParts(t.parts, func(ref *Ref) bool {
+		if err != nil {
+			return false
+		}
+		if _, ok := resolvers[ref.Namespace]; ref.Namespace != "" && !ok {
+			message := fmt.Sprintf("unknown resolver namespace %q", ref.Namespace)
+			if first := ref.Name[0]; first == ' ' || first >= '0' && first <= '9' {
+				message = "invalid substring expression"
+			}
+			err = syntaxError(ref.namespaceOffset, message)
+		}
+		return err == nil
+	})
+	return err
+}
+
 type resolvedValue struct {
 	value string
 	found bool
 }
 
 type evaluator struct {
-	lookup LookupFunc
-	cache  map[string]resolvedValue
+	lookup           LookupFunc
+	resolvers        map[string]Resolver
+	strict           bool
+	allowed          map[string]bool
+	strictNamespaces map[string]bool
+	cache            map[string]resolvedValue
 }
 
-func (e *evaluator) expand(tmpl template) (string, error) {
+func (e *evaluator) expand(parts []Part) (string, error) {
 	var result strings.Builder
-	for _, item := range tmpl.parts {
-		if item.expansion == nil {
-			result.WriteString(item.literal)
+	for _, item := range parts {
+		if item.Ref == nil {
+			result.WriteString(item.Literal)
 			continue
 		}
 
-		value, err := e.expandVariable(*item.expansion)
+		value, err := e.expandReference(item.Ref)
 		if err != nil {
 			return "", err
 		}
@@ -229,50 +446,99 @@ func (e *evaluator) expand(tmpl template) (string, error) {
 	return result.String(), nil
 }
 
-func (e *evaluator) expandVariable(expr expansion) (string, error) {
-	resolved := e.resolve(expr.name)
-	switch expr.op {
-	case opValue:
+func (e *evaluator) expandReference(ref *Ref) (string, error) {
+	resolved, err := e.resolve(ref)
+	if err != nil {
+		return "", err
+	}
+	strict := e.strict || ref.Namespace != "" && e.strictNamespaces[ref.Namespace]
+	if strict && !resolved.found && !handlesUnset(ref.Op) && !e.allowed[ref.Key()] {
+		return "", &RequiredError{Name: ref.Key(), Message: "variable is unset", Offset: ref.Offset}
+	}
+	switch ref.Op {
+	case OpValue:
 		return resolved.value, nil
-	case opDefaultIfUnset:
-		return e.expandWordUnless(expr, resolved.found)
-	case opDefaultIfEmpty:
-		return e.expandWordUnless(expr, resolved.found && resolved.value != "")
-	case opAlternateIfSet:
-		return e.expandWordWhen(expr, resolved.found)
-	case opAlternateIfNonEmpty:
-		return e.expandWordWhen(expr, resolved.found && resolved.value != "")
-	case opRequiredIfUnset:
-		return e.require(expr, resolved, resolved.found, "required variable is unset")
-	case opRequiredIfEmpty:
-		return e.require(expr, resolved, resolved.found && resolved.value != "", "required variable is unset or empty")
-	}
-
-	return "", syntaxError(expr.offset, "unknown operator")
+	case OpDefaultIfUnset:
+		return e.expandWordUnless(ref, resolved, resolved.found)
+	case OpDefaultIfEmpty:
+		return e.expandWordUnless(ref, resolved, resolved.found && resolved.value != "")
+	case OpAlternateIfSet:
+		return e.expandWordWhen(ref, resolved.found)
+	case OpAlternateIfNonEmpty:
+		return e.expandWordWhen(ref, resolved.found && resolved.value != "")
+	case OpRequiredIfUnset:
+		return e.require(ref, resolved, resolved.found, "required variable is unset")
+	case OpRequiredIfEmpty:
+		return e.require(ref, resolved, resolved.found && resolved.value != "", "required variable is unset or empty")
+	case OpRemoveShortestPrefix, OpRemoveLongestPrefix, OpRemoveShortestSuffix, OpRemoveLongestSuffix:
+		pattern, err := e.pattern(ref, true)
+		if err != nil {
+			return "", err
+		}
+		return trimPattern(ref.Op, resolved.value, pattern), nil
+	case OpReplaceFirst, OpReplaceAll:
+		pattern, err := e.pattern(ref, false)
+		if err != nil {
+			return "", err
+		}
+		replacement, err := e.expand(ref.Replacement)
+		if err != nil {
+			return "", err
+		}
+		return replacePattern(resolved.value, pattern, replacement, ref.Op == OpReplaceAll), nil
+	case OpSubstring:
+		return substring(resolved.value, ref.Start, ref.Length, ref.HasLength), nil
+	case OpLength:
+		return strconv.Itoa(utf8.RuneCountInString(resolved.value)), nil
+	case OpUpperFirst, OpUpperAll, OpLowerFirst, OpLowerAll:
+		return modifyCase(ref.Op, resolved.value), nil
+	}
+
+	return "", syntaxError(ref.Offset, "unknown operator")
+}
+
+// handlesUnset reports whether op has a defined result for an unset
+// variable, so WithStrict accepts it.
+func handlesUnset(op Operator) bool {
+	return op >= OpDefaultIfUnset && op <= OpRequiredIfEmpty
 }
 
-func (e *evaluator) expandWordUnless(expr expansion, condition bool) (string, error) {
+// pattern expands the pattern word of ref and compiles it, anchored for the
+// trim operators. A nil pattern matches nothing.
+func (e *evaluator) pattern(ref *Ref, anchored bool) (*regexp.Regexp, error) {
+	text, err := e.expand(ref.Word)
+	if err != nil || text == "" {
+		return nil, err
+	}
+	pattern, err := compilePattern(text, anchored)
+	if err != nil {
+		return nil, syntaxError(ref.wordOffset, fmt.Sprintf("invalid pattern %q", text))
+	}
+	return pattern, nil
+}
+
+func (e *evaluator) expandWordUnless(ref *Ref, resolved resolvedValue, condition bool) (string, error) {
 	if condition {
-		return e.resolve(expr.name).value, nil
+		return resolved.value, nil
 	}
 
-	return e.expand(expr.word)
+	return e.expand(ref.Word)
 }
 
-func (e *evaluator) expandWordWhen(expr expansion, condition bool) (string, error) {
+func (e *evaluator) expandWordWhen(ref *Ref, condition bool) (string, error) {
 	if !condition {
 		return "", nil
 	}
 
-	return e.expand(expr.word)
+	return e.expand(ref.Word)
 }
 
-func (e *evaluator) require(expr expansion, resolved resolvedValue, valid bool, defaultMessage string) (string, error) {
+func (e *evaluator) require(ref *Ref, resolved resolvedValue, valid bool, defaultMessage string) (string, error) {
 	if valid {
 		return resolved.value, nil
 	}
 
-	message, err := e.expand(expr.word)
+	message, err := e.expand(ref.Word)
 	if err != nil {
 		return "", err
 	}
@@ -280,43 +546,81 @@ func (e *evaluator) require(expr expansion, resolved resolvedValue, valid bool,
 		message = defaultMessage
 	}
 
-	return "", &RequiredError{Name: expr.name, Message: message, Offset: expr.offset}
+	return "", &RequiredError{Name: ref.Key(), Message: message, Offset: ref.Offset}
 }
 
-func (e *evaluator) resolve(name string) resolvedValue {
-	if value, ok := e.cache[name]; ok {
-		return value
+func (e *evaluator) resolve(ref *Ref) (resolvedValue, error) {
+	key := ref.Key()
+	if value, ok := e.cache[key]; ok {
+		return value, nil
 	}
 
-	value, found := e.lookup(name)
-	resolved := resolvedValue{value: value, found: found}
-	e.cache[name] = resolved
+	var resolved resolvedValue
+	if ref.Namespace == "" {
+		resolved.value, resolved.found = e.lookup(ref.Name)
+	} else {
+		var err error
+		resolved.value, resolved.found, err = e.resolvers[ref.Namespace](ref.Name)
+		if err != nil {
+			return resolvedValue{}, &ResolverError{Namespace: ref.Namespace, Argument: ref.Name, Offset: ref.Offset, Err: err}
+		}
+	}
+	e.cache[key] = resolved
 
-	return resolved
+	return resolved, nil
 }
 
-// Expand interpolates text using a read-only Docker Compose-style subset:
+// Expand interpolates text using a read-only Docker Compose-style subset,
+// extended with the POSIX and bash string operators:
 //
-//   - ${VAR} substitutes a value, or an empty string when VAR is unset.
+//   - ${VAR} substitutes a value, or an empty string when VAR is unset. With
+//     [WithStrict] an unset VAR is an error instead.
 //   - ${VAR:-word} and ${VAR-word} provide default values.
 //   - ${VAR:+word} and ${VAR+word} provide alternate values.
 //   - ${VAR:?word} and ${VAR?word} require values.
+//   - ${VAR#pattern} and ${VAR##pattern} remove the shortest and longest
+//     matching prefix; ${VAR%pattern} and ${VAR%%pattern} remove a suffix.
+//   - ${VAR/pattern/replacement} replaces the first match of pattern and
+//     ${VAR//pattern/replacement} every match. Each match is the longest one
+//     at its position; an omitted replacement deletes the matches.
+//   - ${VAR:offset} and ${VAR:offset:length} take a substring. A negative
+//     offset counts from the end and needs a space after the colon, as in
+//     ${VAR: -3}; a negative length ends that many characters before the end.
+//   - ${#VAR} is the length of the value in characters.
+//   - ${VAR^}, ${VAR^^}, ${VAR,} and ${VAR,,} convert the first or every
+//     character to upper or lower case.
+//   - ${ns:argument} resolves argument through the resolver registered for
+//     namespace ns with WithResolver, such as ${file:/run/secrets/db}. An
+//     argument of the ${VAR:offset} or ${VAR:offset:length} form is always
+//     a substring, even when ns names a resolver, so ${file:123} is the
+//     substring of the variable file.
 //   - $$ emits a literal dollar sign.
 //
 // A colon makes an operator treat an empty value like an unset variable. Word
 // may contain nested interpolations. Assignment operators are not supported.
-func Expand(text string, lookup LookupFunc) (string, error) {
+// Namespaced references accept the colon operators after their argument, as
+// in ${file:/etc/app/token:-none}; the argument itself is literal text.
+//
+// Patterns are shell patterns matched against whole strings: * matches any
+// string, ? any character, and [...] a character class, negated with [!...]
+// or [^...]. A backslash quotes the next character, so \/ puts a slash in the
+// pattern of / and //; in their replacement \/ and \\ stand for / and \.
+// Patterns may contain nested interpolations; an empty pattern matches
+// nothing. The bash anchors /# and /% are not supported and are read as part
+// of the pattern. An unset variable is treated as empty by
+// these operators, and character offsets, lengths, and case conversion
+// follow Unicode code points.
+//
+// Expand parses text on every call. Use [Parse] and [Template.Execute] to
+// evaluate the same template repeatedly.
+func Expand(text string, lookup LookupFunc, opts ...Option) (string, error) {
 	if lookup == nil {
 		return "", errors.New("templexp: nil lookup function")
 	}
-
-	p := parser{text: text}
-	tmpl, err := p.parse(false, 0)
+	tmpl, err := Parse(text)
 	if err != nil {
 		return "", err
 	}
 
-	e := evaluator{lookup: lookup, cache: make(map[string]resolvedValue)}
-
-	return e.expand(tmpl)
+	return tmpl.Execute(lookup, opts...)
 }